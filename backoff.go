@@ -0,0 +1,83 @@
+package langfuse
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+)
+
+// defaultRetryMaxDelay is the retry delay cap used when none is configured
+const defaultRetryMaxDelay = 30 * time.Second
+
+// BackoffPolicy decides how long the client waits before retrying a failed request
+type BackoffPolicy interface {
+	// Delay returns the wait before the given retry attempt, starting at 1, after the request failed with err
+	Delay(attempt int, err error) time.Duration
+}
+
+// ExponentialBackoff an exponential backoff policy with optional full jitter and Retry-After support.
+// The delay before attempt n is BaseDelay * 2^(n-1) capped at MaxDelay, with full jitter a random delay
+// between zero and that value is used instead so that concurrent processors do not retry in lockstep.
+type ExponentialBackoff struct {
+	// BaseDelay the delay before the first retry
+	BaseDelay time.Duration
+	// MaxDelay the upper bound of the computed delay, zero means no cap
+	MaxDelay time.Duration
+	// Jitter enables full jitter
+	Jitter bool
+	// HonorRetryAfter waits for the duration requested by a Retry-After response header instead of the computed delay,
+	// capped at MaxDelay so that a server cannot park the client for longer
+	HonorRetryAfter bool
+}
+
+// NewBackoffPolicy returns the exponential backoff policy described by the retry settings of the config
+func NewBackoffPolicy(cfg *config.Langfuse) BackoffPolicy {
+	maxDelay := cfg.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	return &ExponentialBackoff{
+		BaseDelay:       cfg.RetryDelay,
+		MaxDelay:        maxDelay,
+		Jitter:          !cfg.DisableRetryJitter,
+		HonorRetryAfter: !cfg.IgnoreRetryAfter,
+	}
+}
+
+// Delay returns the wait before the given retry attempt
+func (b *ExponentialBackoff) Delay(attempt int, err error) time.Duration {
+	if b.HonorRetryAfter {
+		if retryAfter := retryAfter(err); retryAfter > 0 {
+			if b.MaxDelay > 0 {
+				return min(retryAfter, b.MaxDelay)
+			}
+			return retryAfter
+		}
+	}
+
+	delay := float64(b.BaseDelay) * math.Pow(retryBackoffBase, float64(max(attempt-1, 0)))
+	if b.MaxDelay > 0 {
+		delay = min(delay, float64(b.MaxDelay))
+	}
+
+	if b.Jitter {
+		return time.Duration(rand.Float64() * delay) //nolint:gosec // jitter does not need a cryptographic source
+	}
+	return time.Duration(delay)
+}
+
+// retryAfter returns the first wait requested by a Retry-After header in the error chain
+func retryAfter(err error) time.Duration {
+	var langfuseErr *Error
+	for errors.As(err, &langfuseErr) {
+		if langfuseErr.RetryAfter() > 0 {
+			return langfuseErr.RetryAfter()
+		}
+		err = langfuseErr.Cause
+	}
+	return 0
+}
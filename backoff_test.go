@@ -0,0 +1,123 @@
+package langfuse_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/mock"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_ExponentialBackoff_Delay(t *testing.T) {
+	rateLimited := langfuse.NewHTTPError(http.StatusTooManyRequests, "slow down")
+	rateLimited.Details["retry_after"] = 7 * time.Second
+
+	testCases := []struct {
+		name     string
+		policy   langfuse.ExponentialBackoff
+		attempt  int
+		err      error
+		expected time.Duration
+	}{
+		{
+			name:     "first retry waits the base delay",
+			policy:   langfuse.ExponentialBackoff{BaseDelay: time.Second},
+			attempt:  1,
+			expected: time.Second,
+		},
+		{
+			name:     "delay doubles with every attempt",
+			policy:   langfuse.ExponentialBackoff{BaseDelay: time.Second},
+			attempt:  4,
+			expected: 8 * time.Second,
+		},
+		{
+			name:     "delay is capped at the max delay",
+			policy:   langfuse.ExponentialBackoff{BaseDelay: time.Second, MaxDelay: 5 * time.Second},
+			attempt:  10,
+			expected: 5 * time.Second,
+		},
+		{
+			name:     "retry after is honored",
+			policy:   langfuse.ExponentialBackoff{BaseDelay: time.Second, HonorRetryAfter: true},
+			attempt:  1,
+			err:      langfuse.ErrRequestFailed.WithCause(rateLimited),
+			expected: 7 * time.Second,
+		},
+		{
+			name:     "retry after is capped at the max delay",
+			policy:   langfuse.ExponentialBackoff{BaseDelay: time.Second, MaxDelay: 5 * time.Second, HonorRetryAfter: true},
+			attempt:  1,
+			err:      rateLimited,
+			expected: 5 * time.Second,
+		},
+		{
+			name:     "retry after is ignored when not honored",
+			policy:   langfuse.ExponentialBackoff{BaseDelay: time.Second},
+			attempt:  1,
+			err:      rateLimited,
+			expected: time.Second,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.policy.Delay(test.attempt, test.err))
+		})
+	}
+}
+
+func Test_ExponentialBackoff_WithJitter_StaysWithinDelay(t *testing.T) {
+	policy := langfuse.ExponentialBackoff{BaseDelay: time.Second, MaxDelay: 4 * time.Second, Jitter: true}
+
+	for range 100 {
+		delay := policy.Delay(5, nil)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, 4*time.Second)
+	}
+}
+
+func Test_Send_WhenRateLimited_PassesRetryAfterToBackoffPolicy(t *testing.T) {
+	cfg := &config.Langfuse{URL: "http://localhost:3000", MaxRetries: 1}
+	httpClient := &http.Client{}
+	mockTransport := mock.AddMockTransport(t, httpClient)
+	mockTransport.ExpectWith("POST", "http://localhost:3000/api/public/ingestion").Return(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"3"}},
+		Body:       http.NoBody,
+	}, nil)
+	mockTransport.ExpectWith("POST", "http://localhost:3000/api/public/ingestion").ReturnWith(http.StatusOK, "{}")
+	policy := &recordingBackoff{}
+	metrics := langfuse.NewMetricsCollector()
+	eventID := uuid.New()
+
+	err := langfuse.NewClient(cfg, httpClient, langfuse.WithBackoffPolicy(policy), langfuse.WithClientMetrics(metrics)).
+		Send(context.TODO(), &types.TraceEvent{ID: &eventID, Name: "LLM"})
+
+	require.NoError(t, err)
+	require.Len(t, policy.retryAfter, 1)
+	assert.Equal(t, 3*time.Second, policy.retryAfter[0])
+	assert.Equal(t, int64(1), metrics.GetMetrics().HTTPRetries)
+}
+
+// recordingBackoff retries immediately, recording the Retry-After of the errors it was given
+type recordingBackoff struct {
+	retryAfter []time.Duration
+}
+
+func (r *recordingBackoff) Delay(_ int, err error) time.Duration {
+	var langfuseErr *langfuse.Error
+	if errors.As(err, &langfuseErr) {
+		r.retryAfter = append(r.retryAfter, langfuseErr.RetryAfter())
+	}
+	return 0
+}
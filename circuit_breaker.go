@@ -0,0 +1,166 @@
+package langfuse
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+)
+
+const (
+	defaultCircuitBreakerOpenTimeout      = 30 * time.Second // Open timeout used when none is configured
+	defaultCircuitBreakerSuccessThreshold = 1                // Half-open successes needed to close when none is configured
+)
+
+// CircuitState the state of the circuit breaker around the Langfuse API
+type CircuitState string
+
+const (
+	// CircuitClosed requests are sent normally
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen requests fail fast with ErrCircuitOpen until the open timeout expires
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen a single probe request is let through to find out whether the API recovered
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// circuitBreaker stops sending requests after consecutive retryable failures so that an unavailable
+// Langfuse API is not hammered by every processor. After the open timeout a probe request decides
+// whether the circuit closes again.
+type circuitBreaker struct {
+	failureThreshold int
+	successThreshold int
+	openTimeout      time.Duration
+	onStateChange    func(CircuitState)
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+// newCircuitBreaker returns the circuit breaker described by the config, nil when it is disabled
+func newCircuitBreaker(cfg *config.Langfuse, onStateChange func(CircuitState)) *circuitBreaker {
+	if cfg.CircuitBreakerFailureThreshold <= 0 {
+		return nil
+	}
+
+	openTimeout := cfg.CircuitBreakerOpenTimeout
+	if openTimeout <= 0 {
+		openTimeout = defaultCircuitBreakerOpenTimeout
+	}
+	successThreshold := cfg.CircuitBreakerSuccessThreshold
+	if successThreshold <= 0 {
+		successThreshold = defaultCircuitBreakerSuccessThreshold
+	}
+
+	if onStateChange != nil {
+		onStateChange(CircuitClosed)
+	}
+	return &circuitBreaker{
+		failureThreshold: cfg.CircuitBreakerFailureThreshold,
+		successThreshold: successThreshold,
+		openTimeout:      openTimeout,
+		onStateChange:    onStateChange,
+		state:            CircuitClosed,
+	}
+}
+
+// allow returns ErrCircuitOpen when a request must not be sent
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.setState(CircuitHalfOpen)
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record records the outcome of a request, only retryable errors count as failures
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := err != nil && isRetryable(err)
+	switch b.state {
+	case CircuitHalfOpen:
+		b.probing = false
+		if failed {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.successThreshold {
+			b.setState(CircuitClosed)
+		}
+	case CircuitClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.open()
+		}
+	case CircuitOpen:
+		// Outcome of a request sent before the circuit opened
+	}
+}
+
+// State returns the current state, a disabled circuit breaker is always closed
+func (b *circuitBreaker) State() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// open opens the circuit, must be called with the lock held
+func (b *circuitBreaker) open() {
+	b.openedAt = time.Now()
+	b.setState(CircuitOpen)
+}
+
+// setState changes the state and resets the counters, must be called with the lock held
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	if b.onStateChange != nil {
+		b.onStateChange(state)
+	}
+}
+
+// isCircuitOpen returns whether the request was not sent because the circuit breaker is open
+func isCircuitOpen(err error) bool {
+	var langfuseErr *Error
+	return errors.As(err, &langfuseErr) && langfuseErr.Code == ErrCircuitOpen.Code
+}
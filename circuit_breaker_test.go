@@ -0,0 +1,209 @@
+package langfuse_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/mock"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_Client_CircuitBreaker_OpensAfterFailuresAndClosesAfterProbe(t *testing.T) {
+	cfg := &config.Langfuse{
+		URL:                            "http://localhost:3000",
+		CircuitBreakerFailureThreshold: 2,
+		CircuitBreakerOpenTimeout:      time.Millisecond * 50,
+	}
+	transport := &switchableTransport{statusCode: http.StatusServiceUnavailable}
+	metrics := langfuse.NewMetricsCollector()
+	subject := langfuse.NewClient(cfg, &http.Client{Transport: transport}, langfuse.WithClientMetrics(metrics))
+	send := func() error {
+		eventID := uuid.New()
+		return subject.Send(context.TODO(), &types.TraceEvent{ID: &eventID, Name: "LLM"})
+	}
+
+	assert.Equal(t, langfuse.CircuitClosed, metrics.GetMetrics().CircuitBreakerState)
+	require.Error(t, send())
+	assert.Equal(t, langfuse.CircuitClosed, metrics.GetMetrics().CircuitBreakerState)
+	require.Error(t, send())
+	assert.Equal(t, langfuse.CircuitOpen, metrics.GetMetrics().CircuitBreakerState)
+
+	transport.setStatusCode(http.StatusOK)
+	err := send()
+	var langfuseErr *langfuse.Error
+	require.ErrorAs(t, err, &langfuseErr)
+	assert.Equal(t, langfuse.ErrCircuitOpen.Code, langfuseErr.Code)
+	assert.Empty(t, transport.delivered(), "no request is sent while the circuit is open")
+
+	time.Sleep(time.Millisecond * 60)
+	require.NoError(t, send())
+	assert.Equal(t, langfuse.CircuitClosed, metrics.GetMetrics().CircuitBreakerState)
+}
+
+func Test_AddEvent_WhenCircuitIsOpen_HoldsEventsUntilAPIRecovers(t *testing.T) {
+	cfg := circuitBreakerConfig()
+	transport := &switchableTransport{statusCode: http.StatusServiceUnavailable}
+	subject := langfuse.NewWithClient(cfg, &http.Client{Transport: transport})
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	subject.Add(traceWithID("60000000-0000-0000-0000-000000000001"))
+
+	require.Eventually(t, func() bool {
+		return subject.GetMetrics().CircuitBreakerState == langfuse.CircuitOpen
+	}, time.Second*5, time.Millisecond*10)
+	health := subject.CheckHealth(context.TODO())
+	assert.Equal(t, "critical", string(health.Components["circuit_breaker"]))
+	assert.Equal(t, "unhealthy", string(health.Status))
+	assert.Equal(t, int64(0), subject.GetMetrics().EventsFailed)
+
+	transport.setStatusCode(http.StatusOK)
+
+	require.Eventually(t, func() bool {
+		return subject.GetMetrics().EventsProcessed == 1
+	}, time.Second*5, time.Millisecond*10)
+	assert.Contains(t, transport.delivered(), "60000000-0000-0000-0000-000000000001")
+	assert.Equal(t, langfuse.CircuitClosed, subject.GetMetrics().CircuitBreakerState)
+	assert.Equal(t, int64(0), subject.GetMetrics().EventsFailed)
+}
+
+func Test_Stop_WhenCircuitIsOpen_FailsHeldEvents(t *testing.T) {
+	cfg := circuitBreakerConfig()
+	cfg.CircuitBreakerOpenTimeout = time.Hour
+	subject := langfuse.NewWithClient(cfg, &http.Client{Transport: &switchableTransport{statusCode: http.StatusServiceUnavailable}})
+
+	subject.Add(traceWithID("60000000-0000-0000-0000-000000000002"))
+	require.Eventually(t, func() bool {
+		return subject.GetMetrics().CircuitBreakerState == langfuse.CircuitOpen
+	}, time.Second*5, time.Millisecond*10)
+	subject.Add(traceWithID("60000000-0000-0000-0000-000000000003"))
+
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(2), metrics.EventsFailed)
+	assert.Equal(t, langfuse.ErrCircuitOpen.Error(), metrics.LastError)
+}
+
+func Test_AddEvent_WhenCircuitOfProjectIsOpen_KeepsSendingOtherProjects(t *testing.T) {
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		statusCode := http.StatusOK
+		if publicKey, _, _ := request.BasicAuth(); publicKey == "pk-tenant-a" {
+			statusCode = http.StatusServiceUnavailable
+		}
+		return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+	subject := langfuse.NewMultiProject(circuitBreakerConfig(), httpClient)
+	tenantConfig := circuitBreakerConfig()
+	tenantConfig.PublicKey = "pk-tenant-a"
+	tenantConfig.CircuitBreakerOpenTimeout = time.Hour
+	require.NoError(t, subject.RegisterProject("tenant-a", tenantConfig))
+
+	subject.AddEvent(langfuse.ContextWithProject(context.Background(), "tenant-a"), traceWithID("60000000-0000-0000-0000-000000000004"))
+	require.Eventually(t, func() bool {
+		metrics, _ := subject.ProjectMetrics("tenant-a")
+		return metrics.CircuitBreakerState == langfuse.CircuitOpen
+	}, time.Second*5, time.Millisecond*10)
+	for _, id := range []string{"60000000-0000-0000-0000-000000000005", "60000000-0000-0000-0000-000000000006"} {
+		subject.Add(traceWithID(id))
+	}
+
+	require.Eventually(t, func() bool {
+		metrics, _ := subject.ProjectMetrics(langfuse.DefaultProject)
+		return metrics.EventsProcessed == 2
+	}, time.Second*5, time.Millisecond*10)
+	require.NoError(t, subject.Stop(context.TODO()))
+	tenantMetrics, _ := subject.ProjectMetrics("tenant-a")
+	assert.Equal(t, int64(1), tenantMetrics.EventsFailed)
+}
+
+func Test_GetMetrics_WhenCircuitOfOneProjectIsOpen_ReportsItOpen(t *testing.T) {
+	var defaultAvailable atomic.Bool
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		statusCode := http.StatusServiceUnavailable
+		if publicKey, _, _ := request.BasicAuth(); publicKey != "pk-tenant-a" && defaultAvailable.Load() {
+			statusCode = http.StatusOK
+		}
+		return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+	subject := langfuse.NewMultiProject(circuitBreakerConfig(), httpClient)
+	defer func() { _ = subject.Stop(context.TODO()) }()
+	tenantConfig := circuitBreakerConfig()
+	tenantConfig.PublicKey = "pk-tenant-a"
+	tenantConfig.CircuitBreakerOpenTimeout = time.Hour
+	require.NoError(t, subject.RegisterProject("tenant-a", tenantConfig))
+
+	subject.AddEvent(langfuse.ContextWithProject(context.Background(), "tenant-a"), traceWithID("60000000-0000-0000-0000-000000000007"))
+	subject.Add(traceWithID("60000000-0000-0000-0000-000000000008"))
+	require.Eventually(t, func() bool {
+		metrics, _ := subject.ProjectMetrics(langfuse.DefaultProject)
+		return metrics.CircuitBreakerState == langfuse.CircuitOpen
+	}, time.Second*5, time.Millisecond*10)
+
+	// The circuit of the default project closes once it recovers, the one of tenant-a stays open
+	defaultAvailable.Store(true)
+	require.NoError(t, subject.RegisterProject("tenant-b", projectConfig("pk-tenant-b")))
+	require.Eventually(t, func() bool {
+		metrics, _ := subject.ProjectMetrics(langfuse.DefaultProject)
+		return metrics.EventsProcessed == 1 && metrics.CircuitBreakerState == langfuse.CircuitClosed
+	}, time.Second*5, time.Millisecond*10)
+
+	assert.Equal(t, langfuse.CircuitOpen, subject.GetMetrics().CircuitBreakerState)
+	health := subject.CheckHealth(context.TODO())
+	assert.Equal(t, "critical", string(health.Components["circuit_breaker"]))
+}
+
+func Test_AddEvent_WhenTooManyEventsAreHeld_AppliesOverflowPolicy(t *testing.T) {
+	testCases := []struct {
+		name           string
+		policy         config.OverflowPolicy
+		expectedFailed string
+	}{
+		{name: "block fails the new event", policy: config.OverflowBlock, expectedFailed: "60000000-0000-0000-0000-000000000013"},
+		{name: "drop_newest fails the new event", policy: config.OverflowDropNewest, expectedFailed: "60000000-0000-0000-0000-000000000013"},
+		{name: "drop_oldest fails the oldest waiting event", policy: config.OverflowDropOldest, expectedFailed: "60000000-0000-0000-0000-000000000012"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := circuitBreakerConfig()
+			cfg.CircuitBreakerOpenTimeout = time.Hour
+			cfg.QueueCapacity = 2
+			cfg.OverflowPolicy = test.policy
+			recorder := &hookRecorder{}
+			transport := &switchableTransport{statusCode: http.StatusServiceUnavailable}
+			subject := langfuse.NewWithClient(cfg, &http.Client{Transport: transport}, langfuse.WithDeliveryHooks(recorder.hooks()))
+			defer func() { _ = subject.Stop(context.TODO()) }()
+
+			subject.Add(traceWithID("60000000-0000-0000-0000-000000000011"))
+			require.Eventually(t, func() bool {
+				return subject.GetMetrics().CircuitBreakerState == langfuse.CircuitOpen
+			}, time.Second*5, time.Millisecond*10)
+			subject.Add(traceWithID("60000000-0000-0000-0000-000000000012"))
+			subject.Add(traceWithID("60000000-0000-0000-0000-000000000013"))
+
+			require.Eventually(t, func() bool { return len(recorder.get("failed")) == 1 }, time.Second*5, time.Millisecond*10)
+			failed := recorder.get("failed")[0]
+			assert.Equal(t, uuid.MustParse(test.expectedFailed), failed.EventID)
+			assert.ErrorIs(t, failed.Err, langfuse.ErrCircuitOpen)
+		})
+	}
+}
+
+func circuitBreakerConfig() *config.Langfuse {
+	cfg := testConfig()
+	cfg.BatchSize = 1
+	cfg.CircuitBreakerFailureThreshold = 1
+	cfg.CircuitBreakerOpenTimeout = time.Millisecond * 100
+	return cfg
+}
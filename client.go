@@ -8,38 +8,72 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
 
 	"github.com/bdpiprava/GoLangfuse/config"
 	"github.com/bdpiprava/GoLangfuse/logger"
 	"github.com/bdpiprava/GoLangfuse/types"
 )
 
+// Ingestion event types, as reported in DeliveryResult.EventType and used to query a Recorder
+const (
+	EventTypeTraceCreate      = "trace-create"
+	EventTypeGenerationCreate = "generation-create"
+	EventTypeGenerationUpdate = "generation-update"
+	EventTypeSpanCreate       = "span-create"
+	EventTypeSpanUpdate       = "span-update"
+	EventTypeEventCreate      = "event-create"
+	EventTypeScoreCreate      = "score-create"
+)
+
 const (
 	eventTypeUnknown = "unknown"
 
-	retryBackoffBase     = 2    // Base for exponential backoff calculation
-	compressionThreshold = 1024 // Compress payload if > 1KB
-	httpClientErrorStart = 400  // HTTP client error status codes start
+	retryBackoffBase            = 2    // Base for exponential backoff calculation
+	defaultCompressionThreshold = 1024 // Compress payload if > 1KB
+	httpClientErrorStart        = 400  // HTTP client error status codes start
 )
 
 // Client a client interface for sending events to a Langfuse server
 type Client interface {
 	// Send sends ingestion event to langfuse using rest API
 	Send(ctx context.Context, event types.LangfuseEvent) error
-	// SendBatch sends multiple events in a single batch to langfuse
+	// SendBatch sends multiple events in a single batch to langfuse.
+	// When only some events are rejected the returned error lists them, see Error.FailedEvents.
 	SendBatch(ctx context.Context, events []types.LangfuseEvent) error
 }
 
+// Pinger a Client able to check the health of its API, probed by CheckHealth when HealthProbe is configured.
+// Clients created with NewClient implement it.
+type Pinger interface {
+	// Ping calls the Langfuse health endpoint, returns an error when the API is unreachable or reports itself unhealthy
+	Ping(ctx context.Context) error
+}
+
 type client struct {
-	client *http.Client
-	config *config.Langfuse
+	client      *http.Client
+	config      *config.Langfuse
+	credentials config.CredentialProvider
+	metrics     *MetricsCollector
+	backoff     BackoffPolicy
+	breaker     *circuitBreaker
+}
+
+// ClientOption configures optional behaviour of the client created by NewClient
+type ClientOption func(*client)
+
+// WithClientMetrics records request metrics such as bytes sent into the given collector
+func WithClientMetrics(metrics *MetricsCollector) ClientOption {
+	return func(c *client) {
+		c.metrics = metrics
+	}
 }
 
 // NewOptimizedHTTPClient creates an HTTP client optimized for Langfuse API calls
@@ -56,14 +90,36 @@ func NewOptimizedHTTPClient(cfg *config.Langfuse) *http.Client {
 	}
 }
 
+// WithBackoffPolicy replaces the backoff policy built from the config, see NewBackoffPolicy
+func WithBackoffPolicy(policy BackoffPolicy) ClientOption {
+	return func(c *client) {
+		c.backoff = policy
+	}
+}
+
 // NewClient initialise new langfuse api client
 func NewClient(
 	config *config.Langfuse,
 	httpClient *http.Client,
+	opts ...ClientOption,
 ) Client {
-	return &client{
-		client: httpClient,
-		config: config,
+	c := &client{
+		client:      httpClient,
+		config:      config,
+		credentials: config.Credentials(),
+		backoff:     NewBackoffPolicy(config),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.breaker = newCircuitBreaker(config, c.reportCircuitState)
+	return c
+}
+
+// reportCircuitState records circuit breaker state changes in the metrics
+func (c *client) reportCircuitState(state CircuitState) {
+	if c.metrics != nil {
+		c.metrics.UpdateCircuitBreakerState(state)
 	}
 }
 
@@ -81,20 +137,20 @@ func (c client) Send(ctx context.Context, ingestionEvent types.LangfuseEvent) er
 		return ErrUnknownEventType
 	}
 
+	if isUpdateWithoutID(ingestionEvent) {
+		log.Errorf("cannot process %s event without an ID", eventType)
+		return ErrInvalidEventID.WithDetails(map[string]any{
+			"event_type": eventType,
+		})
+	}
+
 	if _, err := govalidator.ValidateStruct(ingestionEvent); err != nil {
 		log.WithError(err).Errorf("ingestion event validation failed")
 		return ErrEventValidation.WithCause(err)
 	}
 
 	request := &ingestionRequest{
-		Batch: []event{
-			{
-				ID:        ingestionEvent.GetID().String(),
-				Body:      ingestionEvent,
-				Type:      eventType,
-				Timestamp: time.Now(),
-			},
-		},
+		Batch: []event{newIngestionEvent(ctx, ingestionEvent, eventType)},
 	}
 
 	resp, err := c.sendEventWithRetry(ctx, request)
@@ -104,9 +160,7 @@ func (c client) Send(ctx context.Context, ingestionEvent types.LangfuseEvent) er
 
 	if len(resp.Errors) > 0 {
 		log.Errorf("request to langfuse returned errors in response %v", resp.Errors)
-		return ErrAPIServerError.WithDetails(map[string]any{
-			"api_errors": resp.Errors,
-		})
+		return request.failures(resp.Errors)[0].Err()
 	}
 
 	return nil
@@ -124,68 +178,140 @@ func (c client) SendBatch(ctx context.Context, events []types.LangfuseEvent) err
 		return nil // Nothing to send
 	}
 
-	// Validate all events first
-	var batchEvents []event
+	request, err := newIngestionRequest(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.sendEventWithRetry(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	// The request succeeded but some events were rejected, report them so that only those are handled again
+	if len(resp.Errors) > 0 {
+		log.Errorf("request to langfuse returned errors in response %v", resp.Errors)
+		failures := request.failures(resp.Errors)
+		return ErrBatchProcessing.WithDetails(map[string]any{
+			"failed_events": failures,
+			"succeeded":     len(events) - len(failures),
+			"batch_size":    len(events),
+		})
+	}
+
+	return nil
+}
+
+// newIngestionRequest validates all events and frames them as a single ingestion request
+func newIngestionRequest(ctx context.Context, events []types.LangfuseEvent) (*ingestionRequest, error) {
+	log := logger.FromContext(ctx)
+	batchEvents := make([]event, 0, len(events))
 	for i, ingestionEvent := range events {
 		eventType := getEventType(ingestionEvent)
 		if eventType == eventTypeUnknown {
 			log.Errorf("cannot process event of 'unknown' type")
-			return ErrUnknownEventType.WithDetails(map[string]any{
+			return nil, ErrUnknownEventType.WithDetails(map[string]any{
 				"event_index": i,
 			})
 		}
 
+		if isUpdateWithoutID(ingestionEvent) {
+			log.Errorf("cannot process %s event without an ID", eventType)
+			return nil, ErrInvalidEventID.WithDetails(map[string]any{
+				"event_index": i,
+				"event_type":  eventType,
+			})
+		}
+
 		if _, err := govalidator.ValidateStruct(ingestionEvent); err != nil {
 			log.WithError(err).Errorf("ingestion event validation failed")
-			return ErrEventValidation.WithCause(err).WithDetails(map[string]any{
+			return nil, ErrEventValidation.WithCause(err).WithDetails(map[string]any{
 				"event_index": i,
 			})
 		}
 
-		batchEvents = append(batchEvents, event{
-			ID:        ingestionEvent.GetID().String(),
-			Body:      ingestionEvent,
-			Type:      eventType,
-			Timestamp: time.Now(),
-		})
+		batchEvents = append(batchEvents, newIngestionEvent(ctx, ingestionEvent, eventType))
 	}
+	return &ingestionRequest{Batch: batchEvents}, nil
+}
 
-	request := &ingestionRequest{
-		Batch: batchEvents,
+// newIngestionEvent frames the event in an ingestion envelope with an ID of its own. Langfuse deduplicates ingestion
+// events by the envelope ID, the create and update events of an observation share the body ID and must not share it.
+// Replayed events keep their original envelope, see withReplayedEnvelopes.
+func newIngestionEvent(ctx context.Context, ingestionEvent types.LangfuseEvent, eventType string) event {
+	if envelope, found := replayedEnvelope(ctx, ingestionEvent); found {
+		return event{ID: envelope.ID, Type: eventType, Timestamp: envelope.Timestamp, Body: ingestionEvent}
 	}
+	return event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Body:      ingestionEvent,
+	}
+}
 
-	resp, err := c.sendEventWithRetry(ctx, request)
+// Ping calls the Langfuse health endpoint once, without retries and regardless of the circuit breaker state
+func (c client) Ping(ctx context.Context) error {
+	apiPath, err := url.JoinPath(c.config.URL, "/api/public/health")
+	if err != nil {
+		return ErrInvalidConfig.WithCause(err).WithDetails(map[string]any{
+			"url": c.config.URL,
+		})
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, apiPath, nil)
 	if err != nil {
+		return ErrRequestFailed.WithCause(err)
+	}
+	if err := c.authenticate(ctx, httpRequest); err != nil {
 		return err
 	}
 
-	if len(resp.Errors) > 0 {
-		log.Errorf("request to langfuse returned errors in response %v", resp.Errors)
-		return ErrBatchProcessing.WithDetails(map[string]any{
-			"api_errors": resp.Errors,
-			"batch_size": len(events),
-		})
+	resp, err := c.client.Do(httpRequest)
+	if err != nil {
+		return ErrConnectionFailed.WithCause(err)
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
+	if resp.StatusCode >= httpClientErrorStart {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return NewHTTPError(resp.StatusCode, string(bodyBytes))
+	}
 	return nil
 }
 
 // sendEventWithRetry sends an ingestion event to langfuse with retry logic
 func (c client) sendEventWithRetry(ctx context.Context, request *ingestionRequest) (*ingestionResponse, error) {
+	// Encode once, every attempt sends the same body
+	payload, err := c.encodeRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
 	var lastErr error
 
 	for i := 0; i <= c.config.MaxRetries; i++ {
 		if i > 0 {
-			// Calculate exponential backoff delay
-			delay := time.Duration(math.Pow(retryBackoffBase, float64(i-1))) * c.config.RetryDelay
+			if c.metrics != nil {
+				c.metrics.IncrementHTTPRetries()
+			}
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
-			case <-time.After(delay):
+			case <-time.After(c.backoff.Delay(i, lastErr)):
 			}
 		}
 
-		resp, err := c.sendEvent(ctx, request)
+		// Fail fast while the circuit breaker is open, the caller decides what happens to the events
+		if err := c.breaker.allow(); err != nil {
+			return nil, err
+		}
+
+		countAttempt(ctx)
+		resp, err := c.sendEvent(ctx, payload)
+		c.breaker.record(err)
 		if err == nil {
 			return resp, nil
 		}
@@ -193,6 +319,11 @@ func (c client) sendEventWithRetry(ctx context.Context, request *ingestionReques
 		lastErr = err
 		log := logger.FromContext(ctx)
 
+		if c.breaker.State() == CircuitOpen {
+			log.WithError(err).Warn("langfuse circuit breaker opened, not retrying")
+			return nil, ErrCircuitOpen.WithCause(err)
+		}
+
 		// Don't retry on client errors (4xx) or non-retryable errors
 		var langfuseErr *Error
 		if errors.As(err, &langfuseErr) {
@@ -212,8 +343,44 @@ func (c client) sendEventWithRetry(ctx context.Context, request *ingestionReques
 	})
 }
 
+// authenticate sets the basic auth header to the current credentials of the project
+func (c client) authenticate(ctx context.Context, httpRequest *http.Request) error {
+	credentials, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return ErrCredentialsUnavailable.WithCause(err)
+	}
+	httpRequest.SetBasicAuth(credentials.PublicKey, credentials.SecretKey)
+	return nil
+}
+
+// encodedRequest an ingestion request as sent over the wire
+type encodedRequest struct {
+	body         []byte
+	compressed   bool
+	originalSize int
+}
+
+// encodeRequest marshals the request and compresses it when worthwhile, see encodePayload
+func (c client) encodeRequest(ctx context.Context, request *ingestionRequest) (*encodedRequest, error) {
+	log := logger.FromContext(ctx)
+	payload, err := json.Marshal(request)
+	if err != nil {
+		log.WithError(err).Error("failed to marshal request payload")
+		return nil, ErrEventProcessing.WithCause(err)
+	}
+
+	body, compressed, err := c.encodePayload(payload)
+	if err != nil {
+		log.WithError(err).Error("failed to compress request payload")
+		return nil, ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+			"operation": "compression",
+		})
+	}
+	return &encodedRequest{body: body, compressed: compressed, originalSize: len(payload)}, nil
+}
+
 // sendEvent send and ingestion event to langfuse
-func (c client) sendEvent(ctx context.Context, request *ingestionRequest) (*ingestionResponse, error) {
+func (c client) sendEvent(ctx context.Context, payload *encodedRequest) (*ingestionResponse, error) {
 	log := logger.FromContext(ctx)
 	apiPath, err := url.JoinPath(c.config.URL, "/api/public/ingestion")
 	if err != nil {
@@ -223,21 +390,24 @@ func (c client) sendEvent(ctx context.Context, request *ingestionRequest) (*inge
 		})
 	}
 
-	payload, err := json.Marshal(request)
-	if err != nil {
-		log.WithError(err).Error("failed to marshal request payload")
-		return nil, ErrEventProcessing.WithCause(err)
-	}
-
-	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, apiPath, bytes.NewBuffer(payload))
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, apiPath, bytes.NewReader(payload.body))
 	if err != nil {
 		log.WithError(err).Error("failed to create langfuse request")
 		return nil, ErrRequestFailed.WithCause(err)
 	}
 
-	httpRequest.SetBasicAuth(c.config.PublicKey, c.config.SecretKey)
+	if err := c.authenticate(ctx, httpRequest); err != nil {
+		log.WithError(err).Error("failed to get langfuse credentials")
+		return nil, err
+	}
 	httpRequest.Header.Set("Content-Type", "application/json")
 	httpRequest.Header.Set("Accept-Encoding", "gzip")
+	if payload.compressed {
+		httpRequest.Header.Set("Content-Encoding", "gzip")
+	}
+	if c.metrics != nil {
+		c.metrics.RecordRequestBytes(int64(len(payload.body)), int64(payload.originalSize-len(payload.body)))
+	}
 
 	resp, err := c.client.Do(httpRequest)
 	if err != nil {
@@ -252,7 +422,11 @@ func (c client) sendEvent(ctx context.Context, request *ingestionRequest) (*inge
 	// Handle HTTP errors
 	if resp.StatusCode >= httpClientErrorStart {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, NewHTTPError(resp.StatusCode, string(bodyBytes))
+		httpErr := NewHTTPError(resp.StatusCode, string(bodyBytes))
+		if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 {
+			httpErr.Details[retryAfterDetail] = retryAfter
+		}
+		return nil, httpErr
 	}
 
 	// Handle compressed response
@@ -287,16 +461,121 @@ func (c client) sendEvent(ctx context.Context, request *ingestionRequest) (*inge
 	return &response, nil
 }
 
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// encodePayload gzip-compresses the payload when it is above the compression threshold and compression is enabled.
+// The payload is sent as is when compression does not make it smaller.
+func (c client) encodePayload(payload []byte) ([]byte, bool, error) {
+	threshold := c.config.CompressionThreshold
+	if threshold <= 0 {
+		threshold = defaultCompressionThreshold
+	}
+	if c.config.DisableRequestCompression || len(payload) <= threshold {
+		return payload, false, nil
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(payload); err != nil {
+		return nil, false, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, false, err
+	}
+
+	if buffer.Len() >= len(payload) {
+		return payload, false, nil
+	}
+	return buffer.Bytes(), true, nil
+}
+
 func getEventType(ingestionEvent types.LangfuseEvent) string {
 	switch ingestionEvent.(type) {
 	case *types.TraceEvent:
-		return "trace-create"
+		return EventTypeTraceCreate
 	case *types.GenerationEvent:
-		return "generation-create"
+		return EventTypeGenerationCreate
+	case *types.GenerationUpdateEvent:
+		return EventTypeGenerationUpdate
 	case *types.SpanEvent:
-		return "span-create"
+		return EventTypeSpanCreate
+	case *types.SpanUpdateEvent:
+		return EventTypeSpanUpdate
+	case *types.EventEvent:
+		return EventTypeEventCreate
 	case *types.ScoreEvent:
-		return "score-create"
+		return EventTypeScoreCreate
 	}
 	return eventTypeUnknown
 }
+
+// failures converts the event errors of the ingestion response, which refer to envelope IDs, to failures of the events
+func (r *ingestionRequest) failures(eventErrs []eventError) []EventFailure {
+	failures := make([]EventFailure, 0, len(eventErrs))
+	for _, eventErr := range eventErrs {
+		index := r.envelopeIndex(eventErr.ID)
+		eventID := eventErr.ID
+		if index >= 0 {
+			eventID = *r.Batch[index].Body.GetID()
+		}
+		failures = append(failures, newEventFailure(index, eventID, eventErr))
+	}
+	return failures
+}
+
+// envelopeIndex returns the position of the envelope with the given ID in the batch, -1 when there is none
+func (r *ingestionRequest) envelopeIndex(envelopeID uuid.UUID) int {
+	for i, ingestionEvent := range r.Batch {
+		if ingestionEvent.ID == envelopeID.String() {
+			return i
+		}
+	}
+	return -1
+}
+
+// newEventFailure converts an event error of the ingestion response for the event at the given position in the batch
+func newEventFailure(index int, eventID uuid.UUID, eventErr eventError) EventFailure {
+	return EventFailure{
+		Index:      index,
+		EventID:    eventID,
+		StatusCode: eventErr.Status,
+		Message:    eventErr.Message,
+		Reason:     eventErr.Error,
+	}
+}
+
+// newEventForType returns an empty event for the given ingestion type, used to decode persisted events
+func newEventForType(eventType string) (types.LangfuseEvent, error) {
+	switch eventType {
+	case EventTypeTraceCreate:
+		return &types.TraceEvent{}, nil
+	case EventTypeGenerationCreate:
+		return &types.GenerationEvent{}, nil
+	case EventTypeGenerationUpdate:
+		return &types.GenerationUpdateEvent{}, nil
+	case EventTypeSpanCreate:
+		return &types.SpanEvent{}, nil
+	case EventTypeSpanUpdate:
+		return &types.SpanUpdateEvent{}, nil
+	case EventTypeEventCreate:
+		return &types.EventEvent{}, nil
+	case EventTypeScoreCreate:
+		return &types.ScoreEvent{}, nil
+	}
+	return nil, ErrUnknownEventType.WithDetails(map[string]any{
+		"event_type": eventType,
+	})
+}
@@ -12,7 +12,7 @@ import (
 
 // event an ingestion event to add trace, span, generation or score to langfuse
 type event struct {
-	ID        string              `json:"id"` // ID an event id
+	ID        string              `json:"id"` // ID the envelope ID, unique per ingestion event
 	Type      string              `json:"type"`
 	Timestamp time.Time           `json:"timestamp"`
 	Metadata  map[string]any      `json:"metadata,omitempty"`
@@ -0,0 +1,100 @@
+// Command langfuse-replay sends events exported to JSONL files to a Langfuse instance, e.g. to load the exports of an
+// air-gapped environment. It reads the batch lines written by langfuse.FileExporter and the event lines written by
+// langfuse.JSONLSink or the spool, including the rotated files of an export.
+//
+// Usage:
+//
+//	langfuse-replay [-config langfuse.yaml] [-rate 100] [-batch-size 100] [-dry-run] events.jsonl...
+//
+// The Langfuse URL and credentials are loaded from the config file and the LANGFUSE_* environment variables.
+// With -dry-run the events are decoded, deduplicated and validated, then written to the standard output instead of
+// being sent, no config is needed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/config"
+)
+
+const (
+	exitFailure = 1
+	exitUsage   = 2
+
+	defaultRate      = 100
+	defaultBatchSize = 100
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run replays the files given in args and returns the exit code, dry runs write the events to stdout
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("langfuse-replay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config", "", "Langfuse config file, the LANGFUSE_* environment variables take precedence")
+	rate := flags.Int("rate", defaultRate, "maximum number of events sent per second, 0 for no limit")
+	batchSize := flags.Int("batch-size", defaultBatchSize, "maximum number of events sent per request")
+	dryRun := flags.Bool("dry-run", false, "write the events that would be sent to the standard output instead of sending them")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "Usage: langfuse-replay [flags] file...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	var paths []string
+	for _, path := range flags.Args() {
+		files, err := langfuse.ExportedFiles(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "langfuse-replay: %v\n", err)
+			return exitFailure
+		}
+		paths = append(paths, files...)
+	}
+
+	options := langfuse.ReplayOptions{BatchSize: *batchSize, Rate: *rate}
+	var client langfuse.Client
+	if *dryRun {
+		client = langfuse.NewJSONLSink(stdout)
+		options.Rate = 0
+	} else {
+		var loadOptions []config.LoadOption
+		if *configFile != "" {
+			loadOptions = append(loadOptions, config.FromFile(*configFile))
+		}
+		cfg, err := config.Load(loadOptions...)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "langfuse-replay: invalid langfuse configuration: %v\n", err)
+			return exitFailure
+		}
+		client = langfuse.NewClient(cfg, langfuse.NewOptimizedHTTPClient(cfg))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := langfuse.Replay(ctx, client, paths, options)
+	_, _ = fmt.Fprintf(stderr, "read %d events: %d sent, %d duplicates, %d failed, %d unreadable\n",
+		result.Read, result.Sent, result.Duplicates, result.Failed, result.Unreadable)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "langfuse-replay: %v\n", err)
+		return exitFailure
+	}
+	if result.Failed > 0 || result.Unreadable > 0 {
+		return exitFailure
+	}
+	return 0
+}
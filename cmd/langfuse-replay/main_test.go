@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_Run(t *testing.T) {
+	t.Setenv("LANGFUSE_URL", "")
+
+	testCases := []struct {
+		name            string
+		args            func(path string) []string
+		unreadable      bool
+		wantCode        int
+		wantStdout      []string
+		wantStderr      string
+		wantEmptyStdout bool
+	}{
+		{
+			name:       "dry run replays rotated files oldest first without config",
+			args:       func(path string) []string { return []string{"-dry-run", path} },
+			wantCode:   0,
+			wantStdout: []string{"first", "second", "third"},
+			wantStderr: "read 3 events: 3 sent, 0 duplicates, 0 failed, 0 unreadable",
+		},
+		{
+			name:       "dry run with unreadable line fails",
+			args:       func(path string) []string { return []string{"-dry-run", path} },
+			unreadable: true,
+			wantCode:   exitFailure,
+			wantStdout: []string{"first", "second", "third"},
+			wantStderr: "read 3 events: 3 sent, 0 duplicates, 0 failed, 1 unreadable",
+		},
+		{
+			name:            "missing config fails",
+			args:            func(path string) []string { return []string{path} },
+			wantCode:        exitFailure,
+			wantStderr:      "langfuse-replay: invalid langfuse configuration",
+			wantEmptyStdout: true,
+		},
+		{
+			name:            "no files is a usage error",
+			args:            func(string) []string { return []string{"-dry-run"} },
+			wantCode:        exitUsage,
+			wantStderr:      "Usage: langfuse-replay [flags] file...",
+			wantEmptyStdout: true,
+		},
+		{
+			name:            "unknown flag is a usage error",
+			args:            func(path string) []string { return []string{"-unknown", path} },
+			wantCode:        exitUsage,
+			wantStderr:      "flag provided but not defined: -unknown",
+			wantEmptyStdout: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeRotatedExport(t, "first", "second", "third")
+			if tc.unreadable {
+				appendLine(t, path, "{not json")
+			}
+			var stdout, stderr bytes.Buffer
+
+			code := run(tc.args(path), &stdout, &stderr)
+
+			assert.Equal(t, tc.wantCode, code)
+			assert.Contains(t, stderr.String(), tc.wantStderr)
+			if tc.wantEmptyStdout {
+				assert.Empty(t, stdout.String())
+				return
+			}
+			assert.Equal(t, tc.wantStdout, traceNames(t, stdout.String()))
+		})
+	}
+}
+
+// writeRotatedExport exports a trace per name, rotating the file after each one
+func writeRotatedExport(t *testing.T, names ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	exporter, err := langfuse.NewFileExporter(path, langfuse.WithExportMaxBytes(1))
+	require.NoError(t, err)
+	for _, name := range names {
+		eventID := uuid.New()
+		require.NoError(t, exporter.Send(context.TODO(), &types.TraceEvent{ID: &eventID, Name: name}))
+	}
+	require.NoError(t, exporter.Close())
+
+	files, err := langfuse.ExportedFiles(path)
+	require.NoError(t, err)
+	require.Len(t, files, len(names))
+	return path
+}
+
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(line + "\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+// traceNames decodes the trace names of the events written by the dry run, in order
+func traceNames(t *testing.T, output string) []string {
+	t.Helper()
+	var names []string
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for decoder.More() {
+		var event struct {
+			Body struct {
+				Name string `json:"name"`
+			} `json:"body"`
+		}
+		require.NoError(t, decoder.Decode(&event))
+		names = append(names, event.Body.Name)
+	}
+	return names
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactedValue replaces secrets in logged configuration
+const redactedValue = "[REDACTED]"
+
+// Credentials the key pair of a Langfuse project
+type Credentials struct {
+	PublicKey string
+	SecretKey string
+}
+
+// String returns the credentials with the secret key redacted, safe to log
+func (c Credentials) String() string {
+	return fmt.Sprintf("{PublicKey:%s SecretKey:%s}", c.PublicKey, redact(c.SecretKey))
+}
+
+// GoString returns the credentials with the secret key redacted for the %#v verb
+func (c Credentials) GoString() string {
+	return fmt.Sprintf("config.Credentials{PublicKey:%q, SecretKey:%q}", c.PublicKey, redact(c.SecretKey))
+}
+
+// CredentialProvider supplies the credentials of the project, consulted on every request to the Langfuse API
+// so that rotated keys are used without restarting. Implementations must be safe for concurrent use.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// StaticCredentials returns a provider of fixed credentials
+func StaticCredentials(publicKey, secretKey string) CredentialProvider {
+	return staticCredentials{PublicKey: publicKey, SecretKey: secretKey}
+}
+
+type staticCredentials Credentials
+
+func (s staticCredentials) Credentials(_ context.Context) (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// String returns the credentials with the secret key redacted, safe to log
+func (s staticCredentials) String() string {
+	return Credentials(s).String()
+}
+
+// GoString returns the credentials with the secret key redacted for the %#v verb
+func (s staticCredentials) GoString() string {
+	return Credentials(s).GoString()
+}
+
+// EnvCredentials returns a provider reading the credentials from the environment variables on every call
+func EnvCredentials(publicKeyVar, secretKeyVar string) CredentialProvider {
+	return envCredentials{publicKeyVar: publicKeyVar, secretKeyVar: secretKeyVar}
+}
+
+type envCredentials struct {
+	publicKeyVar string
+	secretKeyVar string
+}
+
+func (e envCredentials) Credentials(_ context.Context) (Credentials, error) {
+	credentials := Credentials{PublicKey: os.Getenv(e.publicKeyVar), SecretKey: os.Getenv(e.secretKeyVar)}
+	if credentials.PublicKey == "" || credentials.SecretKey == "" {
+		return Credentials{}, fmt.Errorf("environment variables %s and %s must be set", e.publicKeyVar, e.secretKeyVar)
+	}
+	return credentials, nil
+}
+
+// FileCredentials returns a provider reading the credentials from files, e.g. mounted secrets.
+// A file is read again when its modification time or size changed, surrounding whitespace is ignored.
+// While a file that was read before is missing or empty, e.g. in the middle of a rotation, its last content is used.
+func FileCredentials(publicKeyFile, secretKeyFile string) CredentialProvider {
+	return newFileCredentials(Credentials{}, publicKeyFile, secretKeyFile)
+}
+
+// newFileCredentials returns a provider reading the keys whose file is given, the static key is used otherwise
+func newFileCredentials(static Credentials, publicKeyFile, secretKeyFile string) *fileCredentials {
+	provider := &fileCredentials{static: static}
+	if publicKeyFile != "" {
+		provider.publicKey = &watchedFile{path: publicKeyFile}
+	}
+	if secretKeyFile != "" {
+		provider.secretKey = &watchedFile{path: secretKeyFile}
+	}
+	return provider
+}
+
+type fileCredentials struct {
+	mutex     sync.Mutex
+	static    Credentials
+	publicKey *watchedFile
+	secretKey *watchedFile
+}
+
+func (f *fileCredentials) Credentials(_ context.Context) (Credentials, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	credentials := f.static
+	var err error
+	if f.publicKey != nil {
+		if credentials.PublicKey, err = f.publicKey.read(); err != nil {
+			return Credentials{}, err
+		}
+	}
+	if f.secretKey != nil {
+		if credentials.SecretKey, err = f.secretKey.read(); err != nil {
+			return Credentials{}, err
+		}
+	}
+	return credentials, nil
+}
+
+// String returns the configured keys and key files with the secret key redacted, the content of the files is not included
+func (f *fileCredentials) String() string {
+	return fmt.Sprintf("{PublicKey:%s SecretKey:%s PublicKeyFile:%s SecretKeyFile:%s}",
+		f.static.PublicKey, redact(f.static.SecretKey), f.publicKey.name(), f.secretKey.name())
+}
+
+// GoString returns the same as String for the %#v verb
+func (f *fileCredentials) GoString() string {
+	return f.String()
+}
+
+// watchedFile caches the content of a file until it changes
+type watchedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+	content string
+}
+
+// name returns the path of the file, empty when there is none
+func (w *watchedFile) name() string {
+	if w == nil {
+		return ""
+	}
+	return w.path
+}
+
+// read returns the content of the file, reading it again when it changed since the last read.
+// The last content is returned when the file cannot be read anymore, an error only when it was never read.
+func (w *watchedFile) read() (string, error) {
+	value, err := w.reload()
+	if err != nil && w.content != "" {
+		return w.content, nil
+	}
+	return value, err
+}
+
+// reload returns the content of the file, reading it only when it changed since the last successful read
+func (w *watchedFile) reload() (string, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if w.content != "" && info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return w.content, nil
+	}
+
+	content, err := os.ReadFile(w.path) // #nosec G304 -- the path is given by the application
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return "", fmt.Errorf("credentials file %s is empty", w.path)
+	}
+
+	w.modTime, w.size, w.content = info.ModTime(), info.Size(), value
+	return value, nil
+}
+
+// redact masks a secret, empty values stay empty so that a missing secret is still visible
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
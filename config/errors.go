@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// FieldError describes why a configuration field is invalid, listed in the ValidationErrors returned by Validate and Load
+type FieldError struct {
+	// Field the name of the invalid Langfuse field, e.g. BatchSize
+	Field string
+	// Reason why the value is invalid
+	Reason string
+}
+
+// Error returns the field and the reason it is invalid
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// newFieldError creates a FieldError for the field
+func newFieldError(field, reason string) *FieldError {
+	return &FieldError{Field: field, Reason: reason}
+}
+
+// ValidationErrors the fields that failed validation, returned by Validate and Load
+type ValidationErrors []*FieldError
+
+// Error returns every invalid field and the reason it is invalid
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the field errors, so that errors.As finds the first one
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, fieldErr := range e {
+		errs = append(errs, fieldErr)
+	}
+	return errs
+}
+
+// Fields returns the names of the invalid fields
+func (e ValidationErrors) Fields() []string {
+	fields := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		fields = append(fields, fieldErr.Field)
+	}
+	return fields
+}
+
+// orNil returns nil when there are no errors, avoiding a non-nil error interface holding an empty slice
+func (e ValidationErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// structFieldErrors converts a govalidator failure to a FieldError per invalid field
+func structFieldErrors(err error) ValidationErrors {
+	var validatorErrs govalidator.Errors
+	if !errors.As(err, &validatorErrs) {
+		return ValidationErrors{newFieldError("", err.Error())}
+	}
+
+	var fieldErrs ValidationErrors
+	for _, fieldErr := range validatorErrs.Errors() {
+		var validatorErr govalidator.Error
+		if errors.As(fieldErr, &validatorErr) {
+			fieldErrs = append(fieldErrs, newFieldError(validatorErr.Name, validatorErr.Err.Error()))
+		} else {
+			fieldErrs = append(fieldErrs, newFieldError("", fieldErr.Error()))
+		}
+	}
+	return fieldErrs
+}
@@ -3,7 +3,8 @@
 // This package handles loading, validation, and management of configuration
 // parameters required to connect to and interact with the Langfuse API.
 // Configuration can be loaded from environment variables using the envconfig
-// library with automatic validation.
+// library with automatic validation, or with Load from a JSON/YAML file,
+// environment variables and options combined.
 //
 // Example usage:
 //
@@ -13,6 +14,9 @@
 //	    log.Fatalf("Failed to load config: %v", err)
 //	}
 //
+//	// Or combine a file, environment variables and options, later sources win
+//	cfg, err := config.Load(config.FromFile("langfuse.yaml"), config.WithEnvPrefix("TENANT_A"))
+//
 //	// Or create configuration manually
 //	cfg := &config.Langfuse{
 //	    URL:       "https://api.langfuse.com",
@@ -26,6 +30,8 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"net/url"
 	"time"
 
 	"github.com/asaskevich/govalidator"
@@ -40,24 +46,58 @@ import (
 // variable with the LANGFUSE_ prefix.
 //
 // Authentication Configuration:
+//   - Enabled: Whether events are sent at all, New returns a no-op client when disabled
 //   - URL: The Langfuse server endpoint (typically https://api.langfuse.com)
 //   - PublicKey: Your project's public key from the Langfuse dashboard
 //   - SecretKey: Your project's secret key from the Langfuse dashboard
+//   - PublicKeyFile, SecretKeyFile: Files holding the keys, read again when they change
+//   - CredentialProvider: Supplies the keys on every request, e.g. from a secrets manager
 //
 // Performance Configuration:
+//   - SyncMode: Send events on the caller's goroutine instead of queueing them for background processors
 //   - NumberOfEventProcessor: Number of concurrent goroutines processing events
 //   - BatchSize: Maximum number of events to batch together
 //   - BatchTimeout: Maximum time to wait before sending a partial batch
+//   - MaxBatchBytes: Maximum size of a batch request body in bytes
+//   - DisableEventSizeMetrics: Skip serializing every event for the event size histogram
+//
+// Queue Configuration:
+//   - QueueCapacity: Maximum number of events waiting to be processed
+//   - OverflowPolicy: What to do with new events when the queue is full
+//   - EnqueueTimeout: How long to wait for queue space with the block_with_timeout policy
+//
+// Spool Configuration:
+//   - SpoolDir: Directory where undeliverable events are persisted, empty disables the spool
+//   - SpoolMaxBytes: Maximum size of the spool on disk
+//   - SpoolSegmentBytes: Size at which a new spool segment file is started
+//   - SpoolReplayInterval: How often spooled events are replayed
+//   - SpoolReplayRate: Maximum number of spooled events replayed per second
 //
 // HTTP Configuration:
 //   - Timeout: HTTP request timeout for API calls
 //   - MaxIdleConns: Maximum number of idle HTTP connections
 //   - MaxIdleConnsPerHost: Maximum idle connections per host
 //   - IdleConnTimeout: How long to keep idle connections open
+//   - CompressionThreshold: Request body size above which the body is gzip-compressed
+//   - DisableRequestCompression: Send request bodies uncompressed, for servers that reject gzip
 //
 // Reliability Configuration:
 //   - MaxRetries: Maximum number of retry attempts for failed requests
 //   - RetryDelay: Base delay between retry attempts (uses exponential backoff)
+//   - RetryMaxDelay: Upper bound of the delay between retry attempts
+//   - DisableRetryJitter: Use the exact exponential delay instead of full jitter
+//   - IgnoreRetryAfter: Ignore Retry-After headers of rate limited responses
+//   - CircuitBreakerFailureThreshold: Consecutive failures that open the circuit breaker
+//   - CircuitBreakerOpenTimeout: How long the circuit breaker stays open
+//   - CircuitBreakerSuccessThreshold: Successful probes needed to close the circuit breaker
+//
+// Health Configuration:
+//   - HealthQueueUtilizationWarning, HealthQueueUtilizationCritical: Queue utilization ratios reported by health checks
+//   - HealthErrorRateWarning, HealthErrorRateCritical: HTTP error rates reported by health checks
+//   - HealthResponseTimeP99Warning, HealthResponseTimeP99Critical: p99 HTTP response times reported by health checks
+//   - HealthQueueLatencyP99Warning: p99 queue latency reported by health checks
+//   - HealthProbe: Call the Langfuse health endpoint on every health check
+//   - HealthProbeTimeout: Timeout of the health endpoint call
 //
 // Example environment variables:
 //
@@ -69,6 +109,13 @@ import (
 //	LANGFUSE_BATCH_TIMEOUT=5s
 //	LANGFUSE_MAX_RETRIES=3
 type Langfuse struct {
+	// Enabled controls whether events are sent to Langfuse at all. When set to false,
+	// New and NewWithClient return a no-op client and the other settings are not validated,
+	// so environments without credentials, e.g. local development, do not need any.
+	// Default: enabled when not set.
+	// Environment variable: LANGFUSE_ENABLED
+	Enabled *bool `envconfig:"LANGFUSE_ENABLED"`
+
 	// URL is the Langfuse server endpoint.
 	// Required. Must be a valid URL (e.g., https://api.langfuse.com).
 	// Environment variable: LANGFUSE_URL
@@ -77,13 +124,25 @@ type Langfuse struct {
 	// PublicKey is the public key for your Langfuse project.
 	// Required. Obtained from your Langfuse project settings.
 	// Environment variable: LANGFUSE_PUBLIC_KEY
-	PublicKey string `envconfig:"LANGFUSE_PUBLIC_KEY" valid:"required"`
+	PublicKey string `envconfig:"LANGFUSE_PUBLIC_KEY" valid:"-"`
 
 	// SecretKey is the secret key for your Langfuse project.
 	// Required. Obtained from your Langfuse project settings.
 	// Keep this value secure and never expose it in client-side code.
 	// Environment variable: LANGFUSE_SECRET_KEY
-	SecretKey string `envconfig:"LANGFUSE_SECRET_KEY" valid:"required"`
+	SecretKey string `envconfig:"LANGFUSE_SECRET_KEY" valid:"-"`
+
+	// PublicKeyFile and SecretKeyFile are paths of files holding the keys, e.g. mounted secrets.
+	// The files are read again when they change, so rotated keys are used without a restart.
+	// When set they take precedence over PublicKey and SecretKey.
+	// Environment variables: LANGFUSE_PUBLIC_KEY_FILE, LANGFUSE_SECRET_KEY_FILE
+	PublicKeyFile string `envconfig:"LANGFUSE_PUBLIC_KEY_FILE" valid:"-"`
+	SecretKeyFile string `envconfig:"LANGFUSE_SECRET_KEY_FILE" valid:"-"`
+
+	// CredentialProvider supplies the keys on every request to the Langfuse API, e.g. from a secrets manager.
+	// When set it takes precedence over the keys and key files. See StaticCredentials, FileCredentials
+	// and EnvCredentials for the built-in providers.
+	CredentialProvider CredentialProvider `ignored:"true" valid:"-"`
 
 	// NumberOfEventProcessor controls the number of concurrent goroutines
 	// that process and send events to the Langfuse API.
@@ -91,6 +150,13 @@ type Langfuse struct {
 	// Environment variable: LANGFUSE_NUM_OF_EVENT_PROCESSOR
 	NumberOfEventProcessor int `envconfig:"LANGFUSE_NUM_OF_EVENT_PROCESSOR" default:"1"`
 
+	// SyncMode sends every event on the caller's goroutine as soon as it is added, without a queue,
+	// background processors or batching. Intended for scripts and CLI tools where the process exits
+	// right after its work is done. Queue, batch and spool settings are ignored in sync mode.
+	// Default: false.
+	// Environment variable: LANGFUSE_SYNC_MODE
+	SyncMode bool `envconfig:"LANGFUSE_SYNC_MODE" default:"false"`
+
 	// Timeout is the HTTP request timeout for API calls.
 	// Default: 30s. Increase for slow network conditions.
 	// Environment variable: LANGFUSE_TIMEOUT
@@ -114,6 +180,18 @@ type Langfuse struct {
 	// Environment variable: LANGFUSE_IDLE_CONN_TIMEOUT
 	IdleConnTimeout time.Duration `envconfig:"LANGFUSE_IDLE_CONN_TIMEOUT" default:"90s"`
 
+	// CompressionThreshold is the request body size in bytes above which
+	// the body is sent gzip-compressed with Content-Encoding: gzip.
+	// Default: 1024. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_COMPRESSION_THRESHOLD
+	CompressionThreshold int `envconfig:"LANGFUSE_COMPRESSION_THRESHOLD" default:"1024"`
+
+	// DisableRequestCompression sends request bodies uncompressed regardless
+	// of their size, for servers or proxies that reject gzip-encoded requests.
+	// Default: false.
+	// Environment variable: LANGFUSE_DISABLE_REQUEST_COMPRESSION
+	DisableRequestCompression bool `envconfig:"LANGFUSE_DISABLE_REQUEST_COMPRESSION" default:"false"`
+
 	// MaxRetries is the maximum number of retry attempts for failed requests.
 	// Uses exponential backoff with jitter between attempts.
 	// Default: 3. Set to 0 to disable retries.
@@ -121,11 +199,48 @@ type Langfuse struct {
 	MaxRetries int `envconfig:"LANGFUSE_MAX_RETRIES" default:"3"`
 
 	// RetryDelay is the base delay between retry attempts.
-	// Actual delay uses exponential backoff: RetryDelay * (2^attempt) + jitter.
+	// Actual delay uses exponential backoff: RetryDelay * (2^attempt), capped at
+	// RetryMaxDelay, with full jitter picking a random delay up to that value.
 	// Default: 1s.
 	// Environment variable: LANGFUSE_RETRY_DELAY
 	RetryDelay time.Duration `envconfig:"LANGFUSE_RETRY_DELAY" default:"1s"`
 
+	// RetryMaxDelay is the upper bound of the delay between retry attempts,
+	// including the delay requested by a Retry-After header.
+	// Default: 30s. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_RETRY_MAX_DELAY
+	RetryMaxDelay time.Duration `envconfig:"LANGFUSE_RETRY_MAX_DELAY" default:"30s"`
+
+	// DisableRetryJitter uses the exact exponential delay between retry attempts
+	// instead of a random delay up to it.
+	// Default: false.
+	// Environment variable: LANGFUSE_DISABLE_RETRY_JITTER
+	DisableRetryJitter bool `envconfig:"LANGFUSE_DISABLE_RETRY_JITTER" default:"false"`
+
+	// IgnoreRetryAfter ignores the Retry-After header of 429 and 503 responses
+	// and always uses the exponential delay.
+	// Default: false.
+	// Environment variable: LANGFUSE_IGNORE_RETRY_AFTER
+	IgnoreRetryAfter bool `envconfig:"LANGFUSE_IGNORE_RETRY_AFTER" default:"false"`
+
+	// CircuitBreakerFailureThreshold is the number of consecutive retryable request
+	// failures after which the circuit breaker opens and requests fail fast.
+	// Default: 5. A value of 0 disables the circuit breaker.
+	// Environment variable: LANGFUSE_CIRCUIT_BREAKER_FAILURE_THRESHOLD
+	CircuitBreakerFailureThreshold int `envconfig:"LANGFUSE_CIRCUIT_BREAKER_FAILURE_THRESHOLD" default:"5"`
+
+	// CircuitBreakerOpenTimeout is how long the circuit breaker stays open before
+	// a probe request is let through.
+	// Default: 30s. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_CIRCUIT_BREAKER_OPEN_TIMEOUT
+	CircuitBreakerOpenTimeout time.Duration `envconfig:"LANGFUSE_CIRCUIT_BREAKER_OPEN_TIMEOUT" default:"30s"`
+
+	// CircuitBreakerSuccessThreshold is the number of successful probe requests
+	// needed to close the circuit breaker again.
+	// Default: 1. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_CIRCUIT_BREAKER_SUCCESS_THRESHOLD
+	CircuitBreakerSuccessThreshold int `envconfig:"LANGFUSE_CIRCUIT_BREAKER_SUCCESS_THRESHOLD" default:"1"`
+
 	// BatchSize is the maximum number of events to batch together
 	// before sending to the API. Larger batches improve throughput
 	// but increase memory usage and latency.
@@ -138,8 +253,140 @@ type Langfuse struct {
 	// Default: 5s. Lower values reduce latency but may decrease throughput.
 	// Environment variable: LANGFUSE_BATCH_TIMEOUT
 	BatchTimeout time.Duration `envconfig:"LANGFUSE_BATCH_TIMEOUT" default:"5s"`
+
+	// MaxBatchBytes is the maximum size in bytes of the serialized events in a
+	// single batch request. Batches are sent early to stay below this size and
+	// events larger than the limit on their own are rejected.
+	// Default: 3.5MB, the ingestion API request limit. A value of 0 disables the limit.
+	// Environment variable: LANGFUSE_MAX_BATCH_BYTES
+	MaxBatchBytes int `envconfig:"LANGFUSE_MAX_BATCH_BYTES" default:"3500000"`
+
+	// DisableEventSizeMetrics stops recording the serialized size of every
+	// event in the event size histogram. Together with a MaxBatchBytes of 0,
+	// events are no longer serialized before they are batched, which saves
+	// the cost for large events; events that cannot be serialized then fail
+	// with their batch.
+	// Default: false.
+	// Environment variable: LANGFUSE_DISABLE_EVENT_SIZE_METRICS
+	DisableEventSizeMetrics bool `envconfig:"LANGFUSE_DISABLE_EVENT_SIZE_METRICS" default:"false"`
+
+	// QueueCapacity is the maximum number of events that can wait in the queue
+	// before being picked up by an event processor.
+	// Default: 512. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_QUEUE_CAPACITY
+	QueueCapacity int `envconfig:"LANGFUSE_QUEUE_CAPACITY" default:"512"`
+
+	// OverflowPolicy controls what happens when an event is added while the queue is full.
+	// Supported values: block, drop_newest, drop_oldest, block_with_timeout.
+	// Dropped events are counted in the metrics and reported by health checks.
+	// Default: block. An empty value behaves as block.
+	// Environment variable: LANGFUSE_OVERFLOW_POLICY
+	OverflowPolicy OverflowPolicy `envconfig:"LANGFUSE_OVERFLOW_POLICY" default:"block"`
+
+	// EnqueueTimeout is the maximum time to wait for queue space when the
+	// overflow policy is block_with_timeout. The event is dropped afterwards.
+	// Default: 100ms.
+	// Environment variable: LANGFUSE_ENQUEUE_TIMEOUT
+	EnqueueTimeout time.Duration `envconfig:"LANGFUSE_ENQUEUE_TIMEOUT" default:"100ms"`
+
+	// SpoolDir is the directory where events that could not be delivered after
+	// all retries are persisted and replayed once the API recovers.
+	// Events left by a previous run are replayed on startup.
+	// Default: empty, which disables the spool.
+	// Environment variable: LANGFUSE_SPOOL_DIR
+	SpoolDir string `envconfig:"LANGFUSE_SPOOL_DIR"`
+
+	// SpoolMaxBytes is the maximum size of the spool on disk, events are
+	// counted as failed once it is reached.
+	// Default: 100MB. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_SPOOL_MAX_BYTES
+	SpoolMaxBytes int64 `envconfig:"LANGFUSE_SPOOL_MAX_BYTES" default:"104857600"`
+
+	// SpoolSegmentBytes is the size at which the spool starts a new segment file.
+	// Default: 8MB. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_SPOOL_SEGMENT_BYTES
+	SpoolSegmentBytes int64 `envconfig:"LANGFUSE_SPOOL_SEGMENT_BYTES" default:"8388608"`
+
+	// SpoolReplayInterval is how often spooled events are replayed to the API.
+	// Default: 30s. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_SPOOL_REPLAY_INTERVAL
+	SpoolReplayInterval time.Duration `envconfig:"LANGFUSE_SPOOL_REPLAY_INTERVAL" default:"30s"`
+
+	// SpoolReplayRate is the maximum number of spooled events replayed per second,
+	// so that a recovering API is not flooded.
+	// Default: 100. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_SPOOL_REPLAY_RATE
+	SpoolReplayRate int `envconfig:"LANGFUSE_SPOOL_REPLAY_RATE" default:"100"`
+
+	// HealthQueueUtilizationWarning is the queue utilization ratio above which
+	// health checks report a warning.
+	// Default: 0.7. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_QUEUE_UTILIZATION_WARNING
+	HealthQueueUtilizationWarning float64 `envconfig:"LANGFUSE_HEALTH_QUEUE_UTILIZATION_WARNING" default:"0.7"`
+
+	// HealthQueueUtilizationCritical is the queue utilization ratio above which
+	// health checks report the client as unhealthy.
+	// Default: 0.9. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_QUEUE_UTILIZATION_CRITICAL
+	HealthQueueUtilizationCritical float64 `envconfig:"LANGFUSE_HEALTH_QUEUE_UTILIZATION_CRITICAL" default:"0.9"`
+
+	// HealthErrorRateWarning is the HTTP request error rate above which
+	// health checks report a warning.
+	// Default: 0.05. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_ERROR_RATE_WARNING
+	HealthErrorRateWarning float64 `envconfig:"LANGFUSE_HEALTH_ERROR_RATE_WARNING" default:"0.05"`
+
+	// HealthErrorRateCritical is the HTTP request error rate above which
+	// health checks report the client as unhealthy.
+	// Default: 0.1. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_ERROR_RATE_CRITICAL
+	HealthErrorRateCritical float64 `envconfig:"LANGFUSE_HEALTH_ERROR_RATE_CRITICAL" default:"0.1"`
+
+	// HealthResponseTimeP99Warning is the 99th percentile HTTP response time
+	// above which health checks report a warning.
+	// Default: 2s. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_RESPONSE_TIME_P99_WARNING
+	HealthResponseTimeP99Warning time.Duration `envconfig:"LANGFUSE_HEALTH_RESPONSE_TIME_P99_WARNING" default:"2s"`
+
+	// HealthResponseTimeP99Critical is the 99th percentile HTTP response time
+	// above which health checks report the client as unhealthy.
+	// Default: 5s. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_RESPONSE_TIME_P99_CRITICAL
+	HealthResponseTimeP99Critical time.Duration `envconfig:"LANGFUSE_HEALTH_RESPONSE_TIME_P99_CRITICAL" default:"5s"`
+
+	// HealthQueueLatencyP99Warning is the 99th percentile time from adding an
+	// event until it was sent above which health checks report a warning.
+	// Default: 30s. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_QUEUE_LATENCY_P99_WARNING
+	HealthQueueLatencyP99Warning time.Duration `envconfig:"LANGFUSE_HEALTH_QUEUE_LATENCY_P99_WARNING" default:"30s"`
+
+	// HealthProbe makes health checks call the Langfuse health endpoint
+	// (/api/public/health) within the context passed to CheckHealth, so that an
+	// unreachable API is reported before events start failing.
+	// Default: false.
+	// Environment variable: LANGFUSE_HEALTH_PROBE
+	HealthProbe bool `envconfig:"LANGFUSE_HEALTH_PROBE" default:"false"`
+
+	// HealthProbeTimeout is the maximum time the health endpoint call may take.
+	// Default: 5s. A value of 0 uses the default.
+	// Environment variable: LANGFUSE_HEALTH_PROBE_TIMEOUT
+	HealthProbeTimeout time.Duration `envconfig:"LANGFUSE_HEALTH_PROBE_TIMEOUT" default:"5s"`
 }
 
+// OverflowPolicy the behaviour of the event queue when it is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock blocks the caller until there is space in the queue
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropNewest drops the event being added
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowDropOldest drops the oldest queued event to make space for the event being added
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowBlockWithTimeout blocks the caller for at most EnqueueTimeout and drops the event afterwards
+	OverflowBlockWithTimeout OverflowPolicy = "block_with_timeout"
+)
+
 // Validate performs comprehensive validation of the Langfuse configuration.
 //
 // This method validates all configuration parameters to ensure they meet
@@ -150,9 +397,14 @@ type Langfuse struct {
 //   - URL is a valid HTTP/HTTPS URL
 //   - Numeric values are within acceptable ranges
 //   - Duration values are positive
+//   - OverflowPolicy is one of the supported policies
+//   - Spool limits are not negative
+//   - Health thresholds are not negative
+//
+// A disabled configuration, see IsEnabled, is always valid.
 //
-// Returns an error if any validation fails, with a descriptive message
-// indicating which field(s) failed validation.
+// Returns ValidationErrors naming every field that failed validation
+// and the reason, e.g. "BatchSize: must be greater than 0".
 //
 // Example:
 //
@@ -165,19 +417,119 @@ type Langfuse struct {
 //	    log.Fatalf("Configuration is invalid: %v", err)
 //	}
 func (c *Langfuse) Validate() error {
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	var errs ValidationErrors
 	if _, err := govalidator.ValidateStruct(c); err != nil {
-		return fmt.Errorf("configuration validation failed: %w", err)
+		errs = append(errs, structFieldErrors(err)...)
+	}
+
+	if c.CredentialProvider == nil {
+		if c.PublicKey == "" && c.PublicKeyFile == "" {
+			errs = append(errs, newFieldError("PublicKey", "non zero value required"))
+		}
+		if c.SecretKey == "" && c.SecretKeyFile == "" {
+			errs = append(errs, newFieldError("SecretKey", "non zero value required"))
+		}
+	}
+
+	if c.NumberOfEventProcessor <= 0 && !c.SyncMode {
+		errs = append(errs, newFieldError("NumberOfEventProcessor", "must be greater than 0"))
+	}
+
+	if c.BatchSize <= 0 && !c.SyncMode {
+		errs = append(errs, newFieldError("BatchSize", "must be greater than 0"))
+	}
+
+	switch c.OverflowPolicy {
+	case "", OverflowBlock, OverflowDropNewest, OverflowDropOldest, OverflowBlockWithTimeout:
+	default:
+		errs = append(errs, newFieldError("OverflowPolicy", fmt.Sprintf("unsupported overflow policy %q", c.OverflowPolicy)))
+	}
+
+	for _, limit := range c.nonNegativeFields() {
+		if limit.value < 0 {
+			errs = append(errs, newFieldError(limit.field, "must not be negative"))
+		}
 	}
 
-	if c.NumberOfEventProcessor <= 0 {
-		return fmt.Errorf("number of event processors must be greater than 0")
+	return errs.orNil()
+}
+
+// fieldValue the numeric value of a configuration field
+type fieldValue struct {
+	field string
+	value float64
+}
+
+// nonNegativeFields returns the limits and thresholds which must not be negative
+func (c *Langfuse) nonNegativeFields() []fieldValue {
+	return []fieldValue{
+		{field: "CircuitBreakerFailureThreshold", value: float64(c.CircuitBreakerFailureThreshold)},
+		{field: "CircuitBreakerSuccessThreshold", value: float64(c.CircuitBreakerSuccessThreshold)},
+		{field: "MaxBatchBytes", value: float64(c.MaxBatchBytes)},
+		{field: "CompressionThreshold", value: float64(c.CompressionThreshold)},
+		{field: "QueueCapacity", value: float64(c.QueueCapacity)},
+		{field: "SpoolMaxBytes", value: float64(c.SpoolMaxBytes)},
+		{field: "SpoolSegmentBytes", value: float64(c.SpoolSegmentBytes)},
+		{field: "SpoolReplayRate", value: float64(c.SpoolReplayRate)},
+		{field: "HealthQueueUtilizationWarning", value: c.HealthQueueUtilizationWarning},
+		{field: "HealthQueueUtilizationCritical", value: c.HealthQueueUtilizationCritical},
+		{field: "HealthErrorRateWarning", value: c.HealthErrorRateWarning},
+		{field: "HealthErrorRateCritical", value: c.HealthErrorRateCritical},
+		{field: "HealthResponseTimeP99Warning", value: float64(c.HealthResponseTimeP99Warning)},
+		{field: "HealthResponseTimeP99Critical", value: float64(c.HealthResponseTimeP99Critical)},
+		{field: "HealthQueueLatencyP99Warning", value: float64(c.HealthQueueLatencyP99Warning)},
 	}
+}
 
-	if c.BatchSize <= 0 {
-		return fmt.Errorf("batch size must be greater than 0")
+// Credentials returns the provider of the keys used to authenticate requests: the CredentialProvider when set,
+// otherwise a provider reading the configured key files, falling back to PublicKey or SecretKey for a key without file
+func (c *Langfuse) Credentials() CredentialProvider {
+	switch {
+	case c.CredentialProvider != nil:
+		return c.CredentialProvider
+	case c.PublicKeyFile != "" || c.SecretKeyFile != "":
+		return newFileCredentials(Credentials{PublicKey: c.PublicKey, SecretKey: c.SecretKey}, c.PublicKeyFile, c.SecretKeyFile)
+	default:
+		return StaticCredentials(c.PublicKey, c.SecretKey)
 	}
+}
+
+// Redacted returns a copy of the config with the secret key and any password in the URL masked, safe to log.
+// The formatting methods have value receivers so that both the config and a pointer to it are logged redacted.
+func (c Langfuse) Redacted() Langfuse {
+	redacted := c
+	redacted.SecretKey = redact(c.SecretKey)
+	if parsed, err := url.Parse(c.URL); err == nil && parsed.User != nil {
+		redacted.URL = parsed.Redacted()
+	}
+	return redacted
+}
 
-	return nil
+// String returns the config with secrets redacted, so that logging it does not leak the secret key
+func (c Langfuse) String() string {
+	return fmt.Sprintf("%+v", plainLangfuse(c.Redacted()))
+}
+
+// GoString returns the config with secrets redacted for the %#v verb
+func (c Langfuse) GoString() string {
+	return fmt.Sprintf("%#v", plainLangfuse(c.Redacted()))
+}
+
+// LogValue returns the config with secrets redacted for log/slog
+func (c Langfuse) LogValue() slog.Value {
+	return slog.StringValue(c.String())
+}
+
+// plainLangfuse formats the config fields without calling the redacting String method again
+type plainLangfuse Langfuse
+
+// IsEnabled reports whether events are sent to Langfuse, which is the case unless Enabled is set to false
+func (c *Langfuse) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
 
 // LoadLangfuseConfig loads and validates Langfuse configuration from environment variables.
@@ -208,6 +560,8 @@ func (c *Langfuse) Validate() error {
 // Returns a validated Langfuse configuration ready for use, or an error
 // if loading or validation fails.
 //
+// Use Load to read a JSON/YAML file or environment variables with another prefix as well.
+//
 // Example:
 //
 //	// Set environment variables first
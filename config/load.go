@@ -0,0 +1,307 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEnvPrefix the prefix of the environment variables read by Load unless WithEnvPrefix is given
+const defaultEnvPrefix = "LANGFUSE"
+
+// LoadOption configures Load, either a configuration source or a value overriding all sources
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	file      string
+	envPrefix string
+	useEnv    bool
+	overrides []func(*Langfuse)
+}
+
+// FromFile reads the configuration from a JSON or YAML file. Keys are the environment variable names
+// without the LANGFUSE_ prefix in lower case, e.g. url, public_key or batch_timeout.
+func FromFile(path string) LoadOption {
+	return func(o *loadOptions) {
+		o.file = path
+	}
+}
+
+// WithEnvPrefix reads the environment variables with the given prefix instead of LANGFUSE,
+// e.g. with prefix TENANT_A the URL is read from TENANT_A_URL. Useful for several projects in one binary.
+func WithEnvPrefix(prefix string) LoadOption {
+	return func(o *loadOptions) {
+		o.envPrefix = strings.TrimSuffix(prefix, "_")
+	}
+}
+
+// WithoutEnv ignores the environment variables
+func WithoutEnv() LoadOption {
+	return func(o *loadOptions) {
+		o.useEnv = false
+	}
+}
+
+// WithURL sets the Langfuse server endpoint
+func WithURL(url string) LoadOption {
+	return WithOverride(func(c *Langfuse) {
+		c.URL = url
+	})
+}
+
+// WithCredentials sets the public and secret key of the project
+func WithCredentials(publicKey, secretKey string) LoadOption {
+	return WithOverride(func(c *Langfuse) {
+		c.PublicKey = publicKey
+		c.SecretKey = secretKey
+	})
+}
+
+// WithEnabled sets whether events are sent to Langfuse at all
+func WithEnabled(enabled bool) LoadOption {
+	return WithOverride(func(c *Langfuse) {
+		c.Enabled = &enabled
+	})
+}
+
+// WithBatching sets the maximum number of events per batch and how long to wait for a batch to fill up
+func WithBatching(size int, timeout time.Duration) LoadOption {
+	return WithOverride(func(c *Langfuse) {
+		c.BatchSize = size
+		c.BatchTimeout = timeout
+	})
+}
+
+// WithOverride changes the loaded configuration, applied after all sources in the order given
+func WithOverride(override func(*Langfuse)) LoadOption {
+	return func(o *loadOptions) {
+		o.overrides = append(o.overrides, override)
+	}
+}
+
+// Load loads and validates the Langfuse configuration from several sources. Later sources override
+// the values of earlier ones, in this order:
+//  1. the defaults documented on the Langfuse fields
+//  2. the file given with FromFile
+//  3. the LANGFUSE_* environment variables, see WithEnvPrefix and WithoutEnv
+//  4. the options setting values, e.g. WithURL or WithOverride
+//
+// Values that cannot be parsed and fields that fail validation are reported together as ValidationErrors.
+//
+// Example:
+//
+//	cfg, err := config.Load(
+//	    config.FromFile("/etc/myapp/langfuse.yaml"),
+//	    config.WithCredentials(publicKey, secretKey),
+//	)
+func Load(opts ...LoadOption) (*Langfuse, error) {
+	options := &loadOptions{envPrefix: defaultEnvPrefix, useEnv: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	cfg := &Langfuse{}
+	errs := applyDefaults(cfg)
+
+	if options.file != "" {
+		fileErrs, err := applyFile(cfg, options.file)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, fileErrs...)
+	}
+
+	if options.useEnv {
+		errs = append(errs, applyEnv(cfg, options.envPrefix)...)
+	}
+
+	for _, override := range options.overrides {
+		override(cfg)
+	}
+
+	var validationErrs ValidationErrors
+	if err := cfg.Validate(); errors.As(err, &validationErrs) {
+		errs = append(errs, validationErrs...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return cfg, nil
+}
+
+// configField a Langfuse field loadable from a file or an environment variable
+type configField struct {
+	// name the Go field name, e.g. BatchSize
+	name string
+	// key the environment variable name without the LANGFUSE_ prefix, e.g. BATCH_SIZE
+	key string
+	// defaultValue the value of the default tag
+	defaultValue string
+	value        reflect.Value
+}
+
+// configFields returns the fields of the config tagged with an environment variable name
+func configFields(cfg *Langfuse) []configField {
+	structValue := reflect.ValueOf(cfg).Elem()
+	structType := structValue.Type()
+
+	fields := make([]configField, 0, structType.NumField())
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		envName, ok := field.Tag.Lookup("envconfig")
+		if !ok {
+			continue
+		}
+		fields = append(fields, configField{
+			name:         field.Name,
+			key:          strings.TrimPrefix(envName, defaultEnvPrefix+"_"),
+			defaultValue: field.Tag.Get("default"),
+			value:        structValue.Field(i),
+		})
+	}
+	return fields
+}
+
+// applyDefaults sets the fields to the value of their default tag
+func applyDefaults(cfg *Langfuse) ValidationErrors {
+	var errs ValidationErrors
+	for _, field := range configFields(cfg) {
+		if field.defaultValue == "" {
+			continue
+		}
+		if err := field.set(field.defaultValue, "default"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// applyFile sets the fields present in the JSON or YAML file, returns an error when the file cannot be read.
+// Keys without a value, e.g. "public_key:" or null, leave the field unset.
+func applyFile(cfg *Langfuse, path string) (ValidationErrors, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- the path is given by the application
+	if err != nil {
+		return nil, fmt.Errorf("failed to read langfuse config file: %w", err)
+	}
+
+	// YAML is a superset of JSON, so both are decoded the same way
+	values := map[string]any{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse langfuse config file %s: %w", path, err)
+	}
+
+	fieldsByKey := map[string]configField{}
+	for _, field := range configFields(cfg) {
+		fieldsByKey[strings.ToLower(field.key)] = field
+	}
+
+	var errs ValidationErrors
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		value := values[key]
+		field, found := fieldsByKey[strings.ToLower(key)]
+		if !found {
+			errs = append(errs, newFieldError(key, "unknown configuration key in "+path))
+			continue
+		}
+		switch value.(type) {
+		case nil:
+			continue
+		case map[string]any, []any:
+			errs = append(errs, newFieldError(field.name, "must be a single value in "+path))
+			continue
+		}
+		if err := field.set(formatFileValue(value), path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs, nil
+}
+
+// formatFileValue formats a value decoded from the config file like it would be given in an environment variable,
+// numbers are written without an exponent so that e.g. 1e8 sets an integer field
+func formatFileValue(value any) string {
+	switch typed := value.(type) {
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case string:
+		return typed
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+// applyEnv sets the fields whose environment variable is set
+func applyEnv(cfg *Langfuse, prefix string) ValidationErrors {
+	var errs ValidationErrors
+	for _, field := range configFields(cfg) {
+		envName := prefix + "_" + field.key
+		value, found := os.LookupEnv(envName)
+		if !found {
+			continue
+		}
+		if err := field.set(value, envName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// set parses the value from the named source into the field
+func (f configField) set(value, source string) *FieldError {
+	if err := setValue(f.value, strings.TrimSpace(value)); err != nil {
+		return newFieldError(f.name, fmt.Sprintf("invalid value %q from %s: %v", value, source, err))
+	}
+	return nil
+}
+
+// setValue parses the value according to the type of the field
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeFor[time.Duration]() {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Pointer:
+		target := reflect.New(field.Type().Elem())
+		if err := setValue(target.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(target)
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
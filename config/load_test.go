@@ -0,0 +1,167 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+)
+
+func Test_Load_AppliesSourcesInOrderOfPrecedence(t *testing.T) {
+	testCases := []struct {
+		name         string
+		file         string
+		env          map[string]string
+		opts         []config.LoadOption
+		expectations func(*testing.T, *config.Langfuse)
+	}{
+		{
+			name: "defaults are used when no source sets a value",
+			file: "url: http://file:3000\npublic_key: pk-file\nsecret_key: sk-file\n",
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, 1, cfg.NumberOfEventProcessor)
+				assert.Equal(t, 30*time.Second, cfg.Timeout)
+				assert.Equal(t, config.OverflowBlock, cfg.OverflowPolicy)
+				assert.True(t, cfg.IsEnabled())
+			},
+		},
+		{
+			name: "yaml file overrides defaults",
+			file: "url: http://file:3000\npublic_key: pk-file\nsecret_key: sk-file\nbatch_size: 50\nbatch_timeout: 2s\nhealth_error_rate_warning: 0.2\nenabled: false\n",
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, "http://file:3000", cfg.URL)
+				assert.Equal(t, 50, cfg.BatchSize)
+				assert.Equal(t, 2*time.Second, cfg.BatchTimeout)
+				assert.InDelta(t, 0.2, cfg.HealthErrorRateWarning, 0.0001)
+				assert.False(t, cfg.IsEnabled())
+			},
+		},
+		{
+			name: "json file overrides defaults",
+			file: `{"url": "http://file:3000", "public_key": "pk-file", "secret_key": "sk-file", "max_batch_bytes": 1048576}`,
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, 1048576, cfg.MaxBatchBytes)
+			},
+		},
+		{
+			name: "empty values are unset and numbers are formatted without exponent",
+			file: "url: http://file:3000\npublic_key: pk-file\nsecret_key: sk-file\nbatch_size:\nqueue_capacity: null\nspool_max_bytes: 1e8\n",
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, 10, cfg.BatchSize)
+				assert.Equal(t, int64(100000000), cfg.SpoolMaxBytes)
+			},
+		},
+		{
+			name: "environment overrides file",
+			file: "url: http://file:3000\npublic_key: pk-file\nsecret_key: sk-file\nbatch_size: 50\n",
+			env:  map[string]string{"LANGFUSE_BATCH_SIZE": "20", "LANGFUSE_PUBLIC_KEY": "pk-env"},
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, 20, cfg.BatchSize)
+				assert.Equal(t, "pk-env", cfg.PublicKey)
+				assert.Equal(t, "sk-file", cfg.SecretKey)
+			},
+		},
+		{
+			name: "options override environment",
+			env:  map[string]string{"LANGFUSE_URL": "http://env:3000", "LANGFUSE_PUBLIC_KEY": "pk-env", "LANGFUSE_SECRET_KEY": "sk-env"},
+			opts: []config.LoadOption{config.WithURL("http://option:3000"), config.WithBatching(5, time.Second)},
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, "http://option:3000", cfg.URL)
+				assert.Equal(t, "pk-env", cfg.PublicKey)
+				assert.Equal(t, 5, cfg.BatchSize)
+				assert.Equal(t, time.Second, cfg.BatchTimeout)
+			},
+		},
+		{
+			name: "environment prefix selects the project",
+			env: map[string]string{
+				"LANGFUSE_URL": "http://default:3000", "TENANT_A_URL": "http://tenant-a:3000",
+				"TENANT_A_PUBLIC_KEY": "pk-a", "TENANT_A_SECRET_KEY": "sk-a",
+			},
+			opts: []config.LoadOption{config.WithEnvPrefix("TENANT_A")},
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, "http://tenant-a:3000", cfg.URL)
+				assert.Equal(t, "pk-a", cfg.PublicKey)
+			},
+		},
+		{
+			name: "environment is ignored without env",
+			env:  map[string]string{"LANGFUSE_URL": "http://env:3000"},
+			opts: []config.LoadOption{config.WithoutEnv(), config.WithURL("http://option:3000"), config.WithCredentials("pk", "sk")},
+			expectations: func(t *testing.T, cfg *config.Langfuse) {
+				assert.Equal(t, "http://option:3000", cfg.URL)
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			opts := test.opts
+			if test.file != "" {
+				opts = append([]config.LoadOption{config.FromFile(writeConfigFile(t, test.file))}, opts...)
+			}
+
+			cfg, err := config.Load(opts...)
+
+			require.NoError(t, err)
+			test.expectations(t, cfg)
+		})
+	}
+}
+
+func Test_Load_ReportsEveryInvalidField(t *testing.T) {
+	t.Setenv("LANGFUSE_BATCH_TIMEOUT", "soon")
+	path := writeConfigFile(t, "url: http://file:3000\nbatch_size: 0\nunknown_key: 1\n")
+
+	_, err := config.Load(config.FromFile(path))
+
+	var validationErrs config.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, []string{"unknown_key", "BatchTimeout", "PublicKey", "SecretKey", "BatchSize"}, validationErrs.Fields())
+	assert.ErrorContains(t, err, `BatchTimeout: invalid value "soon" from LANGFUSE_BATCH_TIMEOUT`)
+}
+
+func Test_Load_WhenFileValueIsEmpty_ReportsMissingField(t *testing.T) {
+	path := writeConfigFile(t, "url: http://file:3000\npublic_key:\nsecret_key: sk-file\nbatch_size: 1.5\n")
+
+	cfg, err := config.Load(config.FromFile(path))
+
+	assert.Nil(t, cfg)
+	var validationErrs config.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, []string{"BatchSize", "PublicKey"}, validationErrs.Fields())
+	assert.ErrorContains(t, err, `BatchSize: invalid value "1.5" from `+path)
+}
+
+func Test_Validate_ReportsEveryInvalidField(t *testing.T) {
+	cfg := &config.Langfuse{URL: "http://localhost:3000", NumberOfEventProcessor: 1, QueueCapacity: -1, SpoolMaxBytes: -1}
+
+	err := cfg.Validate()
+
+	var validationErrs config.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, []string{"PublicKey", "SecretKey", "BatchSize", "QueueCapacity", "SpoolMaxBytes"}, validationErrs.Fields())
+	var fieldErr *config.FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "PublicKey", fieldErr.Field)
+}
+
+func Test_Load_WhenFileMissing_ReturnsError(t *testing.T) {
+	_, err := config.Load(config.FromFile(filepath.Join(t.TempDir(), "missing.yaml")))
+
+	assert.ErrorContains(t, err, "failed to read langfuse config file")
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "langfuse.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
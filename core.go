@@ -0,0 +1,157 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// serviceCore the state shared by the background and the synchronous Langfuse implementations:
+// the API client, metrics, health checks and the outcome of events added with AddEventWithResult
+type serviceCore struct {
+	client           Client
+	customClient     Client
+	config           *config.Langfuse
+	metricsCollector *MetricsCollector
+	healthMu         sync.RWMutex
+	healthChecks     map[string]HealthCheck
+	hooks            DeliveryHooks
+	router           ProjectRouter
+	deliveriesMu     sync.Mutex
+	deliveries       map[types.LangfuseEvent]*Delivery // keyed by the event instance of a single add
+	failedEvents     atomic.Int64                      // events that will not be delivered, Flush reports those of its run
+	spooledEvents    atomic.Int64                      // events written to the spool, Flush reports those of its run
+}
+
+// newServiceCore creates the shared state for the config and applies the options
+func newServiceCore(config *config.Langfuse, customHTTPClient *http.Client, opts []Option) *serviceCore {
+	metricsCollector := NewMetricsCollector()
+	metricsCollector.SetHealthThresholds(NewHealthThresholds(config))
+
+	core := &serviceCore{
+		client:           NewClient(config, customHTTPClient, WithClientMetrics(metricsCollector)),
+		config:           config,
+		metricsCollector: metricsCollector,
+		healthChecks:     make(map[string]HealthCheck),
+		deliveries:       make(map[types.LangfuseEvent]*Delivery),
+	}
+	for _, opt := range opts {
+		opt(core)
+	}
+	if core.customClient != nil {
+		core.client = core.customClient
+	}
+	return core
+}
+
+// WithClient sends the events with the given Client instead of one created by NewClient for the config,
+// e.g. a FanOutClient or a JSONLSink. The config still configures the queue, batching and health checks.
+func WithClient(client Client) Option {
+	return func(c *serviceCore) {
+		c.customClient = client
+	}
+}
+
+// newOfflineCore creates the shared state of implementations which never call the Langfuse API, i.e. the no-op client
+// and the Recorder. The caller's goroutine handles the events and is reported as a single active processor.
+func newOfflineCore(opts []Option) *serviceCore {
+	metricsCollector := NewMetricsCollector()
+	metricsCollector.UpdateActiveProcessors(syncProcessors)
+
+	core := &serviceCore{
+		config:           &config.Langfuse{},
+		metricsCollector: metricsCollector,
+		healthChecks:     make(map[string]HealthCheck),
+		deliveries:       make(map[types.LangfuseEvent]*Delivery),
+	}
+	for _, opt := range opts {
+		opt(core)
+	}
+	return core
+}
+
+// GetMetrics returns current performance metrics
+func (c *serviceCore) GetMetrics() Metrics {
+	return c.metricsCollector.GetMetrics()
+}
+
+// GetHealthStatus returns current health status
+func (c *serviceCore) GetHealthStatus() HealthStatus {
+	return c.metricsCollector.GetHealthStatus()
+}
+
+// CheckHealth performs health checks and returns status.
+// Besides the metrics based checks, the health endpoint is probed when HealthProbe is configured
+// and the registered health checks are run, all within the given context.
+func (c *serviceCore) CheckHealth(ctx context.Context) HealthStatus {
+	health := c.metricsCollector.assessHealth()
+
+	// Clients without a health endpoint, e.g. custom ones given with WithClient, are not probed
+	if pinger, ok := c.client.(Pinger); ok && c.config.HealthProbe {
+		probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout(c.config))
+		health.addComponent(apiProbeComponent, pinger.Ping(probeCtx))
+		cancel()
+	}
+
+	c.healthMu.RLock()
+	names := slices.Sorted(maps.Keys(c.healthChecks))
+	checks := make([]HealthCheck, 0, len(names))
+	for _, name := range names {
+		checks = append(checks, c.healthChecks[name])
+	}
+	c.healthMu.RUnlock()
+
+	for i, name := range names {
+		health.addComponent(name, checks[i](ctx))
+	}
+
+	c.metricsCollector.storeHealthStatus(health)
+	return health
+}
+
+// RegisterHealthCheck adds a check reported as a component of CheckHealth, replacing a check with the same name
+func (c *serviceCore) RegisterHealthCheck(name string, check HealthCheck) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	c.healthChecks[name] = check
+}
+
+// recordEventSize records the size of the event as encoded in an ingestion request and returns it
+func (c *serviceCore) recordEventSize(ingestionEvent types.LangfuseEvent) (int, error) {
+	if c.config.DisableEventSizeMetrics {
+		return 0, nil
+	}
+
+	size, err := encodedEventSize(ingestionEvent)
+	if err != nil {
+		return 0, err
+	}
+
+	c.metricsCollector.RecordEventSize(size)
+	return size, nil
+}
+
+// encodedEventSize returns the size of the event as encoded in an ingestion request
+func encodedEventSize(ingestionEvent types.LangfuseEvent) (int, error) {
+	encoded, err := json.Marshal(event{
+		ID:        ingestionEvent.GetID().String(),
+		Type:      getEventType(ingestionEvent),
+		Timestamp: time.Now(),
+		Body:      ingestionEvent,
+	})
+	if err != nil {
+		return 0, ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+			"event_id": ingestionEvent.GetID().String(),
+		})
+	}
+
+	return len(encoded), nil
+}
@@ -0,0 +1,193 @@
+package langfuse
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// Option configures the Langfuse service created by NewWithClient
+type Option func(*serviceCore)
+
+// DeliveryResult the outcome of an event added to the service
+type DeliveryResult struct {
+	// EventID the ID of the event
+	EventID uuid.UUID
+	// EventType the ingestion event type, e.g. trace-create
+	EventType string
+	// Err why the event was not delivered, nil when it was delivered
+	Err error
+	// Attempts the number of requests to the Langfuse API that carried the event, including retries
+	Attempts int
+}
+
+// DeliveryHooks callbacks invoked with the outcome of every event. Hooks run on the goroutine that
+// decided the outcome, usually an event processor, so they must return quickly. Nil hooks are skipped.
+type DeliveryHooks struct {
+	// OnDelivered is called once the Langfuse API accepted the event
+	OnDelivered func(DeliveryResult)
+	// OnFailed is called when the event will not be delivered, e.g. it was rejected by the API or retries ran out
+	OnFailed func(DeliveryResult)
+	// OnDropped is called when the event never entered the queue, because it was full or the service was stopped
+	OnDropped func(DeliveryResult)
+}
+
+// WithDeliveryHooks sets the callbacks invoked with the outcome of every event
+func WithDeliveryHooks(hooks DeliveryHooks) Option {
+	return func(c *serviceCore) {
+		c.hooks = hooks
+	}
+}
+
+// Delivery the pending outcome of an event added with AddEventWithResult
+type Delivery struct {
+	id     uuid.UUID
+	done   chan struct{}
+	result DeliveryResult
+}
+
+// newDelivery creates a pending delivery of the event
+func newDelivery(id uuid.UUID) *Delivery {
+	return &Delivery{id: id, done: make(chan struct{})}
+}
+
+// ID returns the ID of the event
+func (d *Delivery) ID() uuid.UUID {
+	return d.id
+}
+
+// Done returns a channel closed once the outcome of the event is known
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Wait waits for the outcome of the event, returns the context error when it expires first.
+// Result.Err is nil when the event was delivered.
+func (d *Delivery) Wait(ctx context.Context) (DeliveryResult, error) {
+	select {
+	case <-d.done:
+		return d.result, nil
+	case <-ctx.Done():
+		return DeliveryResult{EventID: d.id}, ctx.Err()
+	}
+}
+
+// complete records the outcome, must be called once
+func (d *Delivery) complete(result DeliveryResult) {
+	d.result = result
+	close(d.done)
+}
+
+// attemptCounterKey the context key of the counter of requests made by a single send
+type attemptCounterKey struct{}
+
+// withAttemptCounter returns a context counting the requests the client makes with it
+func withAttemptCounter(ctx context.Context) (context.Context, *int) {
+	attempts := new(int)
+	return context.WithValue(ctx, attemptCounterKey{}, attempts), attempts
+}
+
+// countAttempt increments the request counter of the context when there is one
+func countAttempt(ctx context.Context) {
+	if attempts, ok := ctx.Value(attemptCounterKey{}).(*int); ok {
+		*attempts++
+	}
+}
+
+// resendKey the context key marking events the service already handed to the client
+type resendKey struct{}
+
+// withResend returns a context marking the events sent with it as sent before, e.g. after a batch failed.
+// The FanOutClient does not mirror them again.
+func withResend(ctx context.Context) context.Context {
+	return context.WithValue(ctx, resendKey{}, true)
+}
+
+// isResend returns whether the events sent with the context were sent before
+func isResend(ctx context.Context) bool {
+	resend, _ := ctx.Value(resendKey{}).(bool)
+	return resend
+}
+
+// AddEventWithResult adds the event like AddEvent and returns a Delivery to wait for its outcome.
+// The delivery of a spooled event completes with ErrEventSpooled, the outcome of its replay is only reported to the
+// hooks. Deliveries still pending when Stop returns complete with ErrServiceStopped.
+func (l *langfuseService) AddEventWithResult(ctx context.Context, event types.LangfuseEvent) *Delivery {
+	item, err := newEventChanItem(ctx, event)
+	delivery := l.track(item.event)
+	if err != nil {
+		l.rejectEvent(item, err)
+		return delivery
+	}
+	l.add(item)
+	return delivery
+}
+
+// track registers a pending delivery of the event, completed once the outcome of this event instance is known.
+// Deliveries are keyed by the instance rather than the ID, so that events added several times with the same ID,
+// e.g. updates of a trace, complete their own delivery.
+func (c *serviceCore) track(event types.LangfuseEvent) *Delivery {
+	delivery := newDelivery(eventID(event))
+
+	c.deliveriesMu.Lock()
+	c.deliveries[event] = delivery
+	c.deliveriesMu.Unlock()
+	return delivery
+}
+
+// eventDelivered records an event accepted by the API in the given metrics
+func (c *serviceCore) eventDelivered(metrics *MetricsCollector, event types.LangfuseEvent, attempts int) {
+	metrics.IncrementEventsProcessed()
+	c.resolve(c.hooks.OnDelivered, event, newDeliveryResult(event, nil, attempts))
+}
+
+// eventFailed records an event that will not be delivered in the given metrics
+func (c *serviceCore) eventFailed(metrics *MetricsCollector, event types.LangfuseEvent, err error, attempts int) {
+	metrics.IncrementEventsFailed(err)
+	c.failedEvents.Add(1)
+	c.resolve(c.hooks.OnFailed, event, newDeliveryResult(event, err, attempts))
+}
+
+// eventDropped records an event that never entered the queue
+func (c *serviceCore) eventDropped(event types.LangfuseEvent, err error) {
+	c.resolve(c.hooks.OnDropped, event, newDeliveryResult(event, err, 0))
+}
+
+// eventSpooled completes the pending delivery of an event written to the spool with ErrEventSpooled. No hook is
+// called, the replay reports the outcome of the event.
+func (c *serviceCore) eventSpooled(event types.LangfuseEvent, attempts int) {
+	c.spooledEvents.Add(1)
+	c.resolve(nil, event, newDeliveryResult(event, ErrEventSpooled, attempts))
+}
+
+// resolve completes the pending delivery of the event instance, if any, and calls the hook
+func (c *serviceCore) resolve(hook func(DeliveryResult), event types.LangfuseEvent, result DeliveryResult) {
+	c.deliveriesMu.Lock()
+	delivery, found := c.deliveries[event]
+	delete(c.deliveries, event)
+	c.deliveriesMu.Unlock()
+
+	if found {
+		delivery.complete(result)
+	}
+	if hook != nil {
+		hook(result)
+	}
+}
+
+// abandonDeliveries completes the deliveries still pending with ErrServiceStopped
+func (c *serviceCore) abandonDeliveries() {
+	c.deliveriesMu.Lock()
+	defer c.deliveriesMu.Unlock()
+
+	for event, delivery := range c.deliveries {
+		delivery.complete(DeliveryResult{EventID: delivery.id, EventType: getEventType(event), Err: ErrServiceStopped})
+		delete(c.deliveries, event)
+	}
+}
+
+func newDeliveryResult(event types.LangfuseEvent, err error, attempts int) DeliveryResult {
+	return DeliveryResult{EventID: eventID(event), EventType: getEventType(event), Err: err, Attempts: attempts}
+}
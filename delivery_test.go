@@ -0,0 +1,177 @@
+package langfuse_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/mock"
+)
+
+func Test_DeliveryHooks_ReportOutcomeOfEvents(t *testing.T) {
+	testCases := []struct {
+		name             string
+		statusCodes      []int
+		expectedHook     string
+		expectedAttempts int
+		expectedErrCode  string
+	}{
+		{
+			name:             "delivered after a retry",
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedHook:     "delivered",
+			expectedAttempts: 2,
+		},
+		{
+			name:             "rejected by the API",
+			statusCodes:      []int{http.StatusBadRequest, http.StatusBadRequest},
+			expectedHook:     "failed",
+			expectedAttempts: 2, // batch request and individual fallback
+			expectedErrCode:  "CLIENT_ERROR",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.MaxRetries = 1
+			recorder := &hookRecorder{}
+			subject := langfuse.NewWithClient(cfg, sequenceClient(test.statusCodes...), langfuse.WithDeliveryHooks(recorder.hooks()))
+
+			subject.Add(traceWithID("80000000-0000-0000-0000-000000000001"))
+			require.NoError(t, subject.Stop(context.TODO()))
+
+			results := recorder.get(test.expectedHook)
+			require.Len(t, results, 1)
+			assert.Equal(t, uuid.MustParse("80000000-0000-0000-0000-000000000001"), results[0].EventID)
+			assert.Equal(t, "trace-create", results[0].EventType)
+			assert.Equal(t, test.expectedAttempts, results[0].Attempts)
+			if test.expectedErrCode == "" {
+				assert.NoError(t, results[0].Err)
+			} else {
+				assert.ErrorContains(t, results[0].Err, test.expectedErrCode)
+			}
+		})
+	}
+}
+
+func Test_DeliveryHooks_WhenEventAddedAfterStop_ReportsDroppedEvent(t *testing.T) {
+	recorder := &hookRecorder{}
+	subject := langfuse.NewWithClient(testConfig(), sequenceClient(), langfuse.WithDeliveryHooks(recorder.hooks()))
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	subject.Add(traceWithID("80000000-0000-0000-0000-000000000002"))
+
+	results := recorder.get("dropped")
+	require.Len(t, results, 1)
+	assert.ErrorIs(t, results[0].Err, langfuse.ErrServiceStopped)
+	assert.Zero(t, results[0].Attempts)
+}
+
+func Test_AddEventWithResult_WaitsForDelivery(t *testing.T) {
+	subject := langfuse.NewWithClient(testConfig(), sequenceClient(http.StatusOK))
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	delivery := subject.AddEventWithResult(context.TODO(), traceWithID("80000000-0000-0000-0000-000000000003"))
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*5)
+	defer cancel()
+	result, err := delivery.Wait(ctx)
+
+	require.NoError(t, err)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, uuid.MustParse("80000000-0000-0000-0000-000000000003"), delivery.ID())
+	assert.Equal(t, 1, result.Attempts)
+}
+
+func Test_AddEventWithResult_WhenSpooledAtStop_CompletesWithEventSpooled(t *testing.T) {
+	cfg := testConfig()
+	cfg.SpoolDir = t.TempDir()
+	subject := langfuse.NewWithClient(cfg, sequenceClient(http.StatusServiceUnavailable, http.StatusServiceUnavailable))
+
+	delivery := subject.AddEventWithResult(context.TODO(), traceWithID("80000000-0000-0000-0000-000000000004"))
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	select {
+	case <-delivery.Done():
+	default:
+		require.Fail(t, "delivery is still pending after stop")
+	}
+	result, err := delivery.Wait(context.TODO())
+	require.NoError(t, err)
+	assert.ErrorIs(t, result.Err, langfuse.ErrEventSpooled)
+}
+
+func Test_AddEventWithResult_WhenEventsShareID_CompletesEachDelivery(t *testing.T) {
+	subject := langfuse.NewWithClient(testConfig(), sequenceClient(http.StatusOK))
+
+	first := subject.AddEventWithResult(context.TODO(), traceWithID("80000000-0000-0000-0000-000000000005"))
+	update := traceWithID("80000000-0000-0000-0000-000000000005")
+	update.Output = "answer"
+	second := subject.AddEventWithResult(context.TODO(), update)
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	for _, delivery := range []*langfuse.Delivery{first, second} {
+		result, err := delivery.Wait(ctx)
+		require.NoError(t, err)
+		assert.NoError(t, result.Err)
+		assert.Equal(t, 1, result.Attempts)
+	}
+}
+
+// sequenceClient responds with the given status codes in order, repeating the last one
+func sequenceClient(statusCodes ...int) *http.Client {
+	var mutex sync.Mutex
+	return &http.Client{Transport: mock.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		statusCode := http.StatusOK
+		if len(statusCodes) > 0 {
+			statusCode = statusCodes[0]
+		}
+		if len(statusCodes) > 1 {
+			statusCodes = statusCodes[1:]
+		}
+		return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+}
+
+// hookRecorder records the delivery results passed to the hooks
+type hookRecorder struct {
+	mutex   sync.Mutex
+	results map[string][]langfuse.DeliveryResult
+}
+
+func (h *hookRecorder) hooks() langfuse.DeliveryHooks {
+	return langfuse.DeliveryHooks{
+		OnDelivered: func(result langfuse.DeliveryResult) { h.record("delivered", result) },
+		OnFailed:    func(result langfuse.DeliveryResult) { h.record("failed", result) },
+		OnDropped:   func(result langfuse.DeliveryResult) { h.record("dropped", result) },
+	}
+}
+
+func (h *hookRecorder) record(hook string, result langfuse.DeliveryResult) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.results == nil {
+		h.results = make(map[string][]langfuse.DeliveryResult)
+	}
+	h.results[hook] = append(h.results[hook], result)
+}
+
+func (h *hookRecorder) get(hook string) []langfuse.DeliveryResult {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.results[hook]
+}
@@ -34,29 +34,49 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 const (
 	httpServerErrorStart = 500 // HTTP server error status codes start
+
+	retryAfterDetail = "retry_after" // Details key of the wait requested by a Retry-After response header
+
+	redactedDetail = "[REDACTED]" // Replaces secrets in error details
 )
 
+// sensitiveDetailKeys parts of Details keys whose values are never exposed
+var sensitiveDetailKeys = []string{"secret", "password", "authorization", "token"}
+
+// secretKeyPattern matches Langfuse secret keys, e.g. echoed in a response body
+var secretKeyPattern = regexp.MustCompile(`sk-lf-[0-9A-Za-z-]+`)
+
 // Error types matching Langfuse API responses
 var (
 	// Configuration errors
-	ErrInvalidConfig    = &Error{Code: "INVALID_CONFIG", Message: "invalid langfuse configuration", Type: ErrorTypeConfig}
-	ErrMissingURL       = &Error{Code: "MISSING_URL", Message: "langfuse URL is required", Type: ErrorTypeConfig}
-	ErrMissingPublicKey = &Error{Code: "MISSING_PUBLIC_KEY", Message: "langfuse public key is required", Type: ErrorTypeConfig}
-	ErrMissingSecretKey = &Error{Code: "MISSING_SECRET_KEY", Message: "langfuse secret key is required", Type: ErrorTypeConfig}
+	ErrInvalidConfig          = &Error{Code: "INVALID_CONFIG", Message: "invalid langfuse configuration", Type: ErrorTypeConfig}
+	ErrMissingURL             = &Error{Code: "MISSING_URL", Message: "langfuse URL is required", Type: ErrorTypeConfig}
+	ErrMissingPublicKey       = &Error{Code: "MISSING_PUBLIC_KEY", Message: "langfuse public key is required", Type: ErrorTypeConfig}
+	ErrMissingSecretKey       = &Error{Code: "MISSING_SECRET_KEY", Message: "langfuse secret key is required", Type: ErrorTypeConfig}
+	ErrCredentialsUnavailable = &Error{Code: "CREDENTIALS_UNAVAILABLE", Message: "langfuse credentials are unavailable", Type: ErrorTypeConfig}
 
 	// Validation errors
 	ErrEventValidation  = &Error{Code: "EVENT_VALIDATION", Message: "event validation failed", Type: ErrorTypeValidation}
 	ErrUnknownEventType = &Error{Code: "UNKNOWN_EVENT_TYPE", Message: "unknown event type", Type: ErrorTypeValidation}
 	ErrInvalidEventID   = &Error{Code: "INVALID_EVENT_ID", Message: "invalid event ID", Type: ErrorTypeValidation}
+	ErrUnknownProject   = &Error{Code: "UNKNOWN_PROJECT", Message: "langfuse project is not registered", Type: ErrorTypeValidation}
 
 	// Network errors
 	ErrNetworkTimeout   = &Error{Code: "NETWORK_TIMEOUT", Message: "network request timed out", Type: ErrorTypeNetwork}
 	ErrConnectionFailed = &Error{Code: "CONNECTION_FAILED", Message: "failed to connect to langfuse", Type: ErrorTypeNetwork}
 	ErrRequestFailed    = &Error{Code: "REQUEST_FAILED", Message: "HTTP request failed", Type: ErrorTypeNetwork}
+	ErrCircuitOpen      = &Error{Code: "CIRCUIT_OPEN", Message: "langfuse circuit breaker is open", Type: ErrorTypeNetwork}
 
 	// API errors
 	ErrAPIUnauthorized = &Error{Code: "UNAUTHORIZED", Message: "unauthorized access to langfuse API", Type: ErrorTypeAPI}
@@ -69,6 +89,10 @@ var (
 	ErrBatchProcessing = &Error{Code: "BATCH_PROCESSING", Message: "batch processing failed", Type: ErrorTypeProcessing}
 	ErrEventProcessing = &Error{Code: "EVENT_PROCESSING", Message: "event processing failed", Type: ErrorTypeProcessing}
 	ErrServiceStopped  = &Error{Code: "SERVICE_STOPPED", Message: "langfuse service is stopped", Type: ErrorTypeProcessing}
+	ErrSpoolFull       = &Error{Code: "SPOOL_FULL", Message: "langfuse spool size limit reached", Type: ErrorTypeProcessing}
+	ErrEventSpooled    = &Error{Code: "EVENT_SPOOLED", Message: "langfuse event was spooled for replay", Type: ErrorTypeProcessing}
+	ErrFlushIncomplete = &Error{Code: "FLUSH_INCOMPLETE", Message: "flushed events were not all delivered", Type: ErrorTypeProcessing}
+	ErrQueueFull       = &Error{Code: "QUEUE_FULL", Message: "langfuse event queue is full", Type: ErrorTypeProcessing}
 )
 
 // ErrorType represents the category of error
@@ -100,6 +124,11 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
 // WithCause adds a cause to the error
 func (e *Error) WithCause(cause error) *Error {
 	newErr := *e
@@ -110,10 +139,41 @@ func (e *Error) WithCause(cause error) *Error {
 // WithDetails adds details to the error
 func (e *Error) WithDetails(details map[string]any) *Error {
 	newErr := *e
-	newErr.Details = details
+	newErr.Details = redactDetails(details)
 	return &newErr
 }
 
+// redactDetails masks the values of sensitive keys, passwords in URLs and Langfuse secret keys,
+// so that errors can be logged safely
+func redactDetails(details map[string]any) map[string]any {
+	for key, value := range details {
+		if isSensitiveDetail(key) {
+			details[key] = redactedDetail
+			continue
+		}
+		if text, ok := value.(string); ok {
+			details[key] = redactText(text)
+		}
+	}
+	return details
+}
+
+// isSensitiveDetail returns whether the Details key names a secret
+func isSensitiveDetail(key string) bool {
+	key = strings.ToLower(key)
+	return slices.ContainsFunc(sensitiveDetailKeys, func(sensitive string) bool {
+		return strings.Contains(key, sensitive)
+	})
+}
+
+// redactText masks the password of a URL and Langfuse secret keys in the text
+func redactText(text string) string {
+	if parsed, err := url.Parse(text); err == nil && parsed.User != nil {
+		text = parsed.Redacted()
+	}
+	return secretKeyPattern.ReplaceAllString(text, redactedDetail)
+}
+
 // WithStatusCode adds HTTP status code to the error
 func (e *Error) WithStatusCode(statusCode int) *Error {
 	newErr := *e
@@ -123,6 +183,11 @@ func (e *Error) WithStatusCode(statusCode int) *Error {
 
 // IsRetryable returns whether the error is retryable
 func (e *Error) IsRetryable() bool {
+	// Credentials are expected back shortly, e.g. once a rotated key file is written
+	if e.Code == ErrCredentialsUnavailable.Code {
+		return true
+	}
+
 	switch e.Type {
 	case ErrorTypeNetwork:
 		return true
@@ -134,6 +199,12 @@ func (e *Error) IsRetryable() bool {
 	}
 }
 
+// RetryAfter returns the wait requested by the server with a Retry-After header, zero when none was given
+func (e *Error) RetryAfter() time.Duration {
+	retryAfter, _ := e.Details[retryAfterDetail].(time.Duration)
+	return retryAfter
+}
+
 // IsClientError returns whether the error is a client error (4xx)
 func (e *Error) IsClientError() bool {
 	return e.StatusCode >= 400 && e.StatusCode < 500
@@ -175,6 +246,37 @@ func NewHTTPError(statusCode int, message string) *Error {
 	})
 }
 
+// EventFailure the outcome of an event that was not accepted by the ingestion API while the request itself succeeded.
+// Index identifies the event, as the create and update events of an observation share the EventID.
+type EventFailure struct {
+	// Index is the position of the event in the sent batch, -1 when the response refers to an unknown envelope
+	Index      int       `json:"index"`
+	EventID    uuid.UUID `json:"event_id"`
+	StatusCode int       `json:"status_code"`
+	Message    string    `json:"message,omitempty"`
+	Reason     string    `json:"error,omitempty"`
+}
+
+// Err returns the failure as a structured error matching its status code
+func (f EventFailure) Err() *Error {
+	return NewHTTPError(f.StatusCode, f.Message).WithDetails(map[string]any{
+		"event_id": f.EventID.String(),
+		"message":  f.Message,
+		"error":    f.Reason,
+	})
+}
+
+// IsRetryable returns whether sending the event again may succeed
+func (f EventFailure) IsRetryable() bool {
+	return f.Err().IsRetryable()
+}
+
+// FailedEvents returns the events rejected by the ingestion API when the error describes a partially successful batch
+func (e *Error) FailedEvents() []EventFailure {
+	failures, _ := e.Details["failed_events"].([]EventFailure)
+	return failures
+}
+
 // NewValidationError creates a validation error with details
 func NewValidationError(field string, value any, reason string) *Error {
 	return ErrEventValidation.WithDetails(map[string]any{
@@ -200,3 +302,14 @@ func WrapError(err error, baseErr *Error) *Error {
 	}
 	return baseErr.WithCause(err)
 }
+
+// isRetryable returns whether sending again later may succeed, decided by the innermost Error in the chain
+func isRetryable(err error) bool {
+	retryable := false
+	var langfuseErr *Error
+	for errors.As(err, &langfuseErr) {
+		retryable = langfuseErr.IsRetryable()
+		err = langfuseErr.Cause
+	}
+	return retryable
+}
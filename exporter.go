@@ -0,0 +1,206 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+const (
+	defaultExportMaxBytes = 64 << 20 // 64MB
+	defaultExportMaxFiles = 5
+)
+
+// FileExporter a Client appending every batch to a file instead of sending it to Langfuse, e.g. in air-gapped
+// environments where the events are carried over and replayed later with Replay or cmd/langfuse-replay.
+// Each line holds the ingestion request as it would be sent to the API: {"batch":[...]}.
+//
+// The file is rotated once the next batch would exceed the size limit: path becomes path.1, path.1 becomes path.2
+// and so on, the oldest file is removed once there are more rotated files than the limit. Safe for concurrent use.
+type FileExporter struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// FileExporterOption configures a FileExporter
+type FileExporterOption func(*FileExporter)
+
+// WithExportMaxBytes sets the size at which the file is rotated, 64MB by default.
+// A batch larger than the limit is still written, to a file of its own.
+func WithExportMaxBytes(maxBytes int64) FileExporterOption {
+	return func(e *FileExporter) {
+		e.maxBytes = maxBytes
+	}
+}
+
+// WithExportMaxFiles sets the number of rotated files kept next to the current one, 5 by default
+func WithExportMaxFiles(maxFiles int) FileExporterOption {
+	return func(e *FileExporter) {
+		e.maxFiles = maxFiles
+	}
+}
+
+// NewFileExporter creates an exporter appending to the file at path, which is created when missing.
+// Call Close once the service using the exporter is stopped.
+func NewFileExporter(path string, opts ...FileExporterOption) (*FileExporter, error) {
+	e := &FileExporter{
+		path:     path,
+		maxBytes: defaultExportMaxBytes,
+		maxFiles: defaultExportMaxFiles,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.maxBytes <= 0 {
+		return nil, NewConfigError("maxBytes", "must be greater than 0")
+	}
+	if e.maxFiles < 1 {
+		return nil, NewConfigError("maxFiles", "must be at least 1")
+	}
+	if err := e.open(); err != nil {
+		return nil, ErrInvalidConfig.WithCause(err).WithDetails(map[string]any{
+			"path": path,
+		})
+	}
+	return e, nil
+}
+
+// ExportedFiles returns the current and rotated files written by a FileExporter to path, oldest first, i.e. in replay order
+func ExportedFiles(path string) ([]string, error) {
+	files := []string{path}
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return nil, ErrEventProcessing.WithCause(err)
+		}
+		files = append([]string{name}, files...)
+	}
+	return files, nil
+}
+
+// Send appends the event as a batch of one
+func (e *FileExporter) Send(ctx context.Context, event types.LangfuseEvent) error {
+	return e.SendBatch(ctx, []types.LangfuseEvent{event})
+}
+
+// SendBatch validates the events and appends them as a single line, rotating the file first when it is full
+func (e *FileExporter) SendBatch(ctx context.Context, events []types.LangfuseEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	request, err := newIngestionRequest(ctx, events)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(request)
+	if err != nil {
+		return ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+			"operation": "json_marshal",
+		})
+	}
+	line = append(line, '\n')
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.file == nil {
+		return ErrServiceStopped.WithDetails(map[string]any{
+			"path": e.path,
+		})
+	}
+	if e.size > 0 && e.size+int64(len(line)) > e.maxBytes {
+		if err := e.rotate(); err != nil {
+			return ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+				"operation": "rotate",
+			})
+		}
+	}
+
+	written, err := e.file.Write(line)
+	e.size += int64(written)
+	if err == nil {
+		err = e.file.Sync()
+	}
+	if err != nil {
+		return ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+			"operation": "write",
+		})
+	}
+	return nil
+}
+
+// Ping returns nil, the exporter has no remote API
+func (e *FileExporter) Ping(_ context.Context) error {
+	return nil
+}
+
+// Close closes the current file, batches sent afterwards are rejected
+func (e *FileExporter) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
+
+// open opens the current file for appending, must be called with the lock held
+func (e *FileExporter) open() error {
+	file, err := os.OpenFile(filepath.Clean(e.path), os.O_CREATE|os.O_APPEND|os.O_WRONLY, sinkFileMode)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	e.file = file
+	e.size = info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files by one, removing the oldest, and starts a new file, must be called with the lock held
+func (e *FileExporter) rotate() error {
+	if err := e.file.Close(); err != nil {
+		return err
+	}
+	e.file = nil
+
+	names := rotatedNames(e.path, e.maxFiles)
+	if err := os.Remove(names[0]); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := 1; i < len(names); i++ {
+		if err := os.Rename(names[i], names[i-1]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return e.open()
+}
+
+// rotatedNames returns path.maxFiles down to path.1 followed by path, i.e. oldest first
+func rotatedNames(path string, maxFiles int) []string {
+	names := make([]string, 0, maxFiles+1)
+	for i := maxFiles; i > 0; i-- {
+		names = append(names, fmt.Sprintf("%s.%d", path, i))
+	}
+	return append(names, path)
+}
@@ -0,0 +1,98 @@
+package langfuse_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_FileExporter_WritesBatchPerLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	exporter, err := langfuse.NewFileExporter(path)
+	require.NoError(t, err)
+	traceID := uuid.New()
+	spanID := uuid.New()
+
+	require.NoError(t, exporter.SendBatch(context.TODO(), []types.LangfuseEvent{
+		&types.TraceEvent{ID: &traceID, Name: "LLM"},
+		&types.SpanEvent{ID: &spanID, TraceID: &traceID, Name: "retrieval"},
+	}))
+	require.NoError(t, exporter.Send(context.TODO(), &types.TraceEvent{ID: &traceID, Name: "LLM"}))
+	require.NoError(t, exporter.Close())
+
+	lines := readFileLines(t, path)
+	require.Len(t, lines, 2)
+	var request struct {
+		Batch []struct {
+			Type string `json:"type"`
+			Body struct {
+				ID string `json:"id"`
+			} `json:"body"`
+		} `json:"batch"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &request))
+	require.Len(t, request.Batch, 2)
+	assert.Equal(t, spanID.String(), request.Batch[1].Body.ID)
+	assert.Equal(t, langfuse.EventTypeSpanCreate, request.Batch[1].Type)
+}
+
+func Test_FileExporter_RotatesFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	exporter, err := langfuse.NewFileExporter(path, langfuse.WithExportMaxBytes(1), langfuse.WithExportMaxFiles(2))
+	require.NoError(t, err)
+	names := []string{"first", "second", "third", "fourth"}
+
+	for _, name := range names {
+		eventID := uuid.New()
+		require.NoError(t, exporter.Send(context.TODO(), &types.TraceEvent{ID: &eventID, Name: name}))
+	}
+	require.NoError(t, exporter.Close())
+
+	files, err := langfuse.ExportedFiles(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path + ".2", path + ".1", path}, files)
+	for i, file := range files {
+		lines := readFileLines(t, file)
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], `"name":"`+names[i+1]+`"`)
+	}
+}
+
+func Test_NewFileExporter_WhenOptionInvalid_ReturnsConfigError(t *testing.T) {
+	testCases := []struct {
+		name          string
+		option        langfuse.FileExporterOption
+		expectedField string
+	}{
+		{name: "max bytes not positive", option: langfuse.WithExportMaxBytes(0), expectedField: "maxBytes"},
+		{name: "no rotated files", option: langfuse.WithExportMaxFiles(0), expectedField: "maxFiles"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := langfuse.NewFileExporter(filepath.Join(t.TempDir(), "events.jsonl"), test.option)
+
+			var langfuseErr *langfuse.Error
+			require.ErrorAs(t, err, &langfuseErr)
+			assert.Equal(t, langfuse.ErrInvalidConfig.Code, langfuseErr.Code)
+			assert.Equal(t, test.expectedField, langfuseErr.Details["field"])
+		})
+	}
+}
+
+func readFileLines(t *testing.T, path string) []string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
@@ -0,0 +1,225 @@
+package langfuse
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/logger"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// defaultMirrorQueueCapacity is the number of batches waiting for a mirror when none is configured
+const defaultMirrorQueueCapacity = 100
+
+// FanOutTarget a Client the events are delivered to by a FanOutClient
+type FanOutTarget struct {
+	// Name identifies the target in logs and in TargetMetrics, e.g. self-hosted or cloud, and must be unique
+	Name string
+	// Client sends the events, with its own retries and circuit breaker when created by NewClient
+	Client Client
+	// QueueCapacity is the number of batches waiting to be sent to a mirror, further batches are dropped
+	// and counted in the metrics of the mirror. 100 when not set, not used for the primary.
+	QueueCapacity int
+}
+
+// FanOutClient a Client delivering every event to a primary target and to any number of mirrors, e.g. to send the same
+// events to a self-hosted and a cloud Langfuse during a migration, or to keep a local copy with a JSONLSink.
+//
+// Each target retries and fails on its own: only the outcome of the primary is returned, so the service retries,
+// spools and reports events based on the primary alone. Mirrors are sent to in the background by a worker per mirror,
+// so that a slow mirror does not delay the primary; batches that do not fit in the queue of a mirror are dropped.
+// Mirror failures and drops are logged and counted in the metrics of the mirror, see TargetMetrics. Events are
+// mirrored once: the events the service sends again after a primary failure, held back by the circuit breaker or
+// replayed from the spool are only sent to the primary. Call Close once the service using the client is stopped.
+type FanOutClient struct {
+	primary FanOutTarget
+	mirrors []*mirror
+	metrics map[string]*MetricsCollector
+
+	mutex   sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// mirror a mirror target and the queue of batches waiting to be sent to it
+type mirror struct {
+	FanOutTarget
+	queue chan mirrorBatch
+}
+
+// mirrorBatch a batch waiting to be sent to a mirror
+type mirrorBatch struct {
+	ctx    context.Context
+	events []types.LangfuseEvent
+	send   sendFunc
+}
+
+// sendFunc sends the events with the client, i.e. calls Send or SendBatch
+type sendFunc func(ctx context.Context, client Client, events []types.LangfuseEvent) error
+
+// NewFanOutClient creates a client delivering every event to the primary and the mirrors and starts a worker per mirror.
+// Use it with WithClient. Returns an *Error with code INVALID_CONFIG when two targets have the same name.
+func NewFanOutClient(primary FanOutTarget, mirrors ...FanOutTarget) (*FanOutClient, error) {
+	f := &FanOutClient{primary: primary, metrics: make(map[string]*MetricsCollector, len(mirrors)+1)}
+	for _, target := range append([]FanOutTarget{primary}, mirrors...) {
+		if _, found := f.metrics[target.Name]; found {
+			return nil, NewConfigError("name", "must be unique, "+target.Name+" is used by several targets")
+		}
+		f.metrics[target.Name] = NewMetricsCollector()
+	}
+
+	for _, target := range mirrors {
+		capacity := target.QueueCapacity
+		if capacity <= 0 {
+			capacity = defaultMirrorQueueCapacity
+		}
+		m := &mirror{FanOutTarget: target, queue: make(chan mirrorBatch, capacity)}
+		f.mirrors = append(f.mirrors, m)
+		f.workers.Add(1)
+		go func() {
+			defer f.workers.Done()
+			f.runMirror(m)
+		}()
+	}
+	return f, nil
+}
+
+// Send sends the event to every target, returns the error of the primary
+func (f *FanOutClient) Send(ctx context.Context, event types.LangfuseEvent) error {
+	return f.fanOut(ctx, []types.LangfuseEvent{event}, func(ctx context.Context, client Client, events []types.LangfuseEvent) error {
+		return client.Send(ctx, events[0])
+	})
+}
+
+// SendBatch sends the events to every target, returns the error of the primary
+func (f *FanOutClient) SendBatch(ctx context.Context, events []types.LangfuseEvent) error {
+	return f.fanOut(ctx, events, func(ctx context.Context, client Client, events []types.LangfuseEvent) error {
+		return client.SendBatch(ctx, events)
+	})
+}
+
+// Ping calls the health endpoint of the primary when it is a Pinger, mirrors do not affect the health of the service
+func (f *FanOutClient) Ping(ctx context.Context) error {
+	if pinger, ok := f.primary.Client.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// TargetMetrics returns the events and batches delivered to, failed and dropped by the named target, false when there is none
+func (f *FanOutClient) TargetMetrics(name string) (Metrics, bool) {
+	metrics, found := f.metrics[name]
+	if !found {
+		return Metrics{}, false
+	}
+	return metrics.GetMetrics(), true
+}
+
+// Close stops mirroring and waits until the batches queued for the mirrors were sent, returns the context error when
+// it expired first. Batches sent afterwards are only delivered to the primary.
+func (f *FanOutClient) Close(ctx context.Context) error {
+	f.mutex.Lock()
+	if !f.closed {
+		f.closed = true
+		for _, m := range f.mirrors {
+			close(m.queue)
+		}
+	}
+	f.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fanOut queues the events for the mirrors unless the service sends them again and sends them to the primary,
+// returning once the primary was sent to
+func (f *FanOutClient) fanOut(ctx context.Context, events []types.LangfuseEvent, send sendFunc) error {
+	if len(f.mirrors) > 0 && !isResend(ctx) {
+		f.enqueueMirrors(ctx, events, send)
+	}
+	return f.sendTo(ctx, f.primary, events, send)
+}
+
+// enqueueMirrors queues a copy of the events for every mirror, dropping it for the mirrors whose queue is full
+func (f *FanOutClient) enqueueMirrors(ctx context.Context, events []types.LangfuseEvent, send sendFunc) {
+	// The mirrors send after the caller returned: the copies may not be modified by the caller, the context is not
+	// cancelled with the caller's and the requests of a mirror are not counted as attempts of the delivery
+	mirrored := make([]types.LangfuseEvent, 0, len(events))
+	for _, event := range events {
+		mirrored = append(mirrored, event.Clone())
+	}
+	mirrorCtx, _ := withAttemptCounter(context.WithoutCancel(ctx))
+	batch := mirrorBatch{ctx: mirrorCtx, events: mirrored, send: send}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	for _, m := range f.mirrors {
+		queued := false
+		if !f.closed {
+			select {
+			case m.queue <- batch:
+				queued = true
+			default:
+			}
+		}
+		if !queued {
+			logger.FromContext(ctx).Warnf("mirror queue of %s is full or closed, dropping %d events", m.Name, len(events))
+			for range events {
+				f.metrics[m.Name].IncrementEventsDropped()
+			}
+		}
+	}
+}
+
+// runMirror sends the batches queued for the mirror until the queue is closed
+func (f *FanOutClient) runMirror(m *mirror) {
+	for batch := range m.queue {
+		if err := f.sendTo(batch.ctx, m.FanOutTarget, batch.events, batch.send); err != nil {
+			logger.FromContext(batch.ctx).WithError(err).Warnf("failed to mirror %d events to %s", len(batch.events), m.Name)
+		}
+	}
+}
+
+// sendTo sends the events to the target and records the outcome in its metrics
+func (f *FanOutClient) sendTo(ctx context.Context, target FanOutTarget, events []types.LangfuseEvent, send sendFunc) error {
+	metrics := f.metrics[target.Name]
+	startTime := time.Now()
+	err := send(ctx, target.Client, events)
+	failures := failedEvents(err)
+	metrics.RecordHTTPRequest(err == nil || len(failures) > 0, time.Since(startTime))
+
+	switch {
+	case err == nil:
+		metrics.IncrementBatchesProcessed()
+		incrementEvents(metrics, len(events), nil)
+	case len(failures) > 0:
+		metrics.IncrementBatchesProcessed()
+		incrementEvents(metrics, len(events)-len(failures), nil)
+		incrementEvents(metrics, len(failures), err)
+	default:
+		metrics.IncrementBatchesFailed(err)
+		incrementEvents(metrics, len(events), err)
+	}
+	return err
+}
+
+// incrementEvents counts the events as processed, or as failed when err is given
+func incrementEvents(metrics *MetricsCollector, count int, err error) {
+	for range count {
+		if err != nil {
+			metrics.IncrementEventsFailed(err)
+		} else {
+			metrics.IncrementEventsProcessed()
+		}
+	}
+}
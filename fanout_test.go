@@ -0,0 +1,213 @@
+package langfuse_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_FanOutClient_AccountsTargetsIndependently(t *testing.T) {
+	serverErr := langfuse.NewHTTPError(http.StatusServiceUnavailable, "unavailable")
+	testCases := []struct {
+		name                   string
+		primaryErr             error
+		mirrorErr              error
+		expectPrimaryProcessed int64
+		expectMirrorProcessed  int64
+	}{
+		{
+			name:                   "both targets accept the events",
+			expectPrimaryProcessed: 2,
+			expectMirrorProcessed:  2,
+		},
+		{
+			name:                   "mirror failure is not returned",
+			mirrorErr:              serverErr,
+			expectPrimaryProcessed: 2,
+		},
+		{
+			name:                  "primary failure is returned and the mirror still receives the events",
+			primaryErr:            serverErr,
+			expectMirrorProcessed: 2,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			primary := &stubClient{err: test.primaryErr}
+			mirror := &stubClient{err: test.mirrorErr}
+			subject, err := langfuse.NewFanOutClient(
+				langfuse.FanOutTarget{Name: "self-hosted", Client: primary},
+				langfuse.FanOutTarget{Name: "cloud", Client: mirror},
+			)
+			require.NoError(t, err)
+
+			err = subject.SendBatch(context.TODO(), []types.LangfuseEvent{&types.TraceEvent{Name: "a"}, &types.TraceEvent{Name: "b"}})
+			require.NoError(t, subject.Close(context.TODO()))
+
+			assert.Equal(t, test.primaryErr, err)
+			assert.Equal(t, 2, primary.count())
+			assert.Equal(t, 2, mirror.count())
+			assertTargetMetrics(t, subject, "self-hosted", test.expectPrimaryProcessed, test.primaryErr)
+			assertTargetMetrics(t, subject, "cloud", test.expectMirrorProcessed, test.mirrorErr)
+		})
+	}
+}
+
+func Test_FanOutClient_WithService_DeliversToEverySink(t *testing.T) {
+	var primaryOutput, mirrorOutput bytes.Buffer
+	fanOut, err := langfuse.NewFanOutClient(
+		langfuse.FanOutTarget{Name: "primary", Client: langfuse.NewJSONLSink(&primaryOutput)},
+		langfuse.FanOutTarget{Name: "mirror", Client: langfuse.NewJSONLSink(&mirrorOutput)},
+	)
+	require.NoError(t, err)
+	subject := langfuse.NewWithClient(testConfig(), &http.Client{}, langfuse.WithClient(fanOut))
+	eventID := uuid.New()
+
+	delivery := subject.AddEventWithResult(context.TODO(), &types.TraceEvent{ID: &eventID, Name: "LLM"})
+	result, err := delivery.Wait(context.TODO())
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+	require.NoError(t, subject.Stop(context.TODO()))
+	require.NoError(t, fanOut.Close(context.TODO()))
+
+	for _, output := range []string{primaryOutput.String(), mirrorOutput.String()} {
+		assert.Equal(t, 1, strings.Count(output, "\n"))
+		assert.Contains(t, output, `"id":"`+eventID.String()+`"`)
+	}
+	assert.Equal(t, int64(1), subject.GetMetrics().EventsProcessed)
+}
+
+func Test_FanOutClient_WithService_MirrorsEventsOnce(t *testing.T) {
+	primary := &stubClient{err: langfuse.NewHTTPError(http.StatusServiceUnavailable, "unavailable")}
+	var mirrorOutput bytes.Buffer
+	fanOut, err := langfuse.NewFanOutClient(
+		langfuse.FanOutTarget{Name: "primary", Client: primary},
+		langfuse.FanOutTarget{Name: "mirror", Client: langfuse.NewJSONLSink(&mirrorOutput)},
+	)
+	require.NoError(t, err)
+	cfg := testConfig()
+	cfg.BatchSize = 2
+	subject := langfuse.NewWithClient(cfg, &http.Client{}, langfuse.WithClient(fanOut))
+
+	subject.Add(&types.TraceEvent{Name: "a"})
+	subject.Add(&types.TraceEvent{Name: "b"})
+	require.NoError(t, subject.Stop(context.TODO()))
+	require.NoError(t, fanOut.Close(context.TODO()))
+
+	assert.Equal(t, 4, primary.count(), "the events are sent to the primary in the batch and on their own")
+	assert.Equal(t, 2, strings.Count(mirrorOutput.String(), "\n"), "the events sent on their own are not mirrored again")
+}
+
+func Test_FanOutClient_WhenMirrorIsSlow_ReturnsAfterPrimary(t *testing.T) {
+	primary := &stubClient{}
+	mirror := &stubClient{started: make(chan struct{}, 3), release: make(chan struct{})}
+	subject, err := langfuse.NewFanOutClient(
+		langfuse.FanOutTarget{Name: "primary", Client: primary},
+		langfuse.FanOutTarget{Name: "mirror", Client: mirror, QueueCapacity: 1},
+	)
+	require.NoError(t, err)
+
+	// First batch blocks in the mirror, second one waits in its queue and the third one does not fit
+	require.NoError(t, subject.Send(context.TODO(), &types.TraceEvent{Name: "a"}))
+	<-mirror.started
+	sent := make(chan error, 1)
+	go func() {
+		_ = subject.Send(context.TODO(), &types.TraceEvent{Name: "b"})
+		sent <- subject.SendBatch(context.TODO(), []types.LangfuseEvent{&types.TraceEvent{Name: "c"}, &types.TraceEvent{Name: "d"}})
+	}()
+	select {
+	case err := <-sent:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "sending waited for the mirror")
+	}
+	assert.Equal(t, 4, primary.count())
+	metrics, _ := subject.TargetMetrics("mirror")
+	assert.Equal(t, int64(2), metrics.EventsDropped)
+
+	close(mirror.release)
+	require.NoError(t, subject.Close(context.TODO()))
+	assert.Equal(t, 2, mirror.count())
+	metrics, _ = subject.TargetMetrics("mirror")
+	assert.Equal(t, int64(2), metrics.EventsProcessed)
+}
+
+func Test_NewFanOutClient_WhenNamesAreNotUnique_ReturnsConfigError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mirrors []langfuse.FanOutTarget
+	}{
+		{name: "mirror named as the primary", mirrors: []langfuse.FanOutTarget{{Name: "primary", Client: &stubClient{}}}},
+		{name: "mirrors with the same name", mirrors: []langfuse.FanOutTarget{{Name: "cloud", Client: &stubClient{}}, {Name: "cloud", Client: &stubClient{}}}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			subject, err := langfuse.NewFanOutClient(langfuse.FanOutTarget{Name: "primary", Client: &stubClient{}}, test.mirrors...)
+
+			assert.Nil(t, subject)
+			var langfuseErr *langfuse.Error
+			require.ErrorAs(t, err, &langfuseErr)
+			assert.Equal(t, "INVALID_CONFIG", langfuseErr.Code)
+			assert.Equal(t, "name", langfuseErr.Details["field"])
+		})
+	}
+}
+
+func assertTargetMetrics(t *testing.T, subject *langfuse.FanOutClient, name string, expectedProcessed int64, expectedErr error) {
+	t.Helper()
+	metrics, found := subject.TargetMetrics(name)
+	require.True(t, found)
+	assert.Equal(t, expectedProcessed, metrics.EventsProcessed)
+	if expectedErr == nil {
+		assert.Zero(t, metrics.EventsFailed)
+		assert.Equal(t, int64(1), metrics.BatchesProcessed)
+		return
+	}
+	assert.Equal(t, int64(2), metrics.EventsFailed)
+	assert.Equal(t, int64(1), metrics.BatchesFailed)
+	assert.Equal(t, expectedErr.Error(), metrics.LastError)
+}
+
+// stubClient a Client counting the events it received and returning err, waiting for release when it is set
+type stubClient struct {
+	err     error
+	started chan struct{}
+	release chan struct{}
+
+	mutex    sync.Mutex
+	received int
+}
+
+func (s *stubClient) Send(ctx context.Context, event types.LangfuseEvent) error {
+	return s.SendBatch(ctx, []types.LangfuseEvent{event})
+}
+
+func (s *stubClient) SendBatch(_ context.Context, events []types.LangfuseEvent) error {
+	if s.release != nil {
+		s.started <- struct{}{}
+		<-s.release
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.received += len(events)
+	return s.err
+}
+
+func (s *stubClient) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.received
+}
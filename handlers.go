@@ -0,0 +1,85 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// HealthChecker performs health checks, implemented by Langfuse
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) HealthStatus
+}
+
+// livenessResponse the body written by the liveness handler
+type livenessResponse struct {
+	Status           HealthStatusValue `json:"status"`
+	ActiveProcessors int               `json:"active_processors"`
+}
+
+// HTTPStatusCode returns the HTTP status code health endpoints respond with for the status.
+// Healthy and degraded clients are still able to send events and respond with 200 OK,
+// any other status responds with 503 Service Unavailable.
+func (v HealthStatusValue) HTTPStatusCode() int {
+	switch v {
+	case healthStatusHealthy, healthStatusDegraded:
+		return http.StatusOK
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+// NewLivenessHandler returns an http.Handler reporting whether the event processors are running.
+// It does not perform any health check so it is cheap enough for a liveness probe, responding
+// 200 OK while at least one processor is active and 503 Service Unavailable otherwise.
+//
+// Example:
+//
+//	mux.Handle("/healthz", langfuse.NewLivenessHandler(client))
+func NewLivenessHandler(provider MetricsProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		response := livenessResponse{Status: healthStatusHealthy, ActiveProcessors: provider.GetMetrics().ActiveProcessors}
+		if response.ActiveProcessors == 0 {
+			response.Status = healthStatusUnhealthy
+		}
+		writeJSON(w, response.Status.HTTPStatusCode(), response)
+	})
+}
+
+// NewReadinessHandler returns an http.Handler running CheckHealth within the request context and
+// writing the HealthStatus as JSON, with the status code given by HealthStatusValue.HTTPStatusCode.
+//
+// Example:
+//
+//	mux.Handle("/readyz", langfuse.NewReadinessHandler(client))
+func NewReadinessHandler(checker HealthChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := checker.CheckHealth(r.Context())
+		writeJSON(w, health.Status.HTTPStatusCode(), health)
+	})
+}
+
+// NewMetricsHandler returns an http.Handler writing the Metrics as JSON.
+// See NewPrometheusHandler for the Prometheus text exposition format.
+//
+// Example:
+//
+//	mux.Handle("/metrics.json", langfuse.NewMetricsHandler(client))
+func NewMetricsHandler(provider MetricsProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, provider.GetMetrics())
+	})
+}
+
+// writeJSON writes the value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,99 @@
+package langfuse_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+)
+
+func Test_ReadinessHandler_MapsHealthStatusToStatusCode(t *testing.T) {
+	testCases := []struct {
+		status             langfuse.HealthStatusValue
+		expectedStatusCode int
+	}{
+		{status: "healthy", expectedStatusCode: http.StatusOK},
+		{status: "degraded", expectedStatusCode: http.StatusOK},
+		{status: "unhealthy", expectedStatusCode: http.StatusServiceUnavailable},
+		{status: "unknown", expectedStatusCode: http.StatusServiceUnavailable},
+	}
+
+	for _, test := range testCases {
+		t.Run(string(test.status), func(t *testing.T) {
+			checker := healthCheckerFunc(func(context.Context) langfuse.HealthStatus {
+				return langfuse.HealthStatus{Status: test.status, Warnings: []string{"Recent errors detected"}}
+			})
+			recorder := httptest.NewRecorder()
+
+			langfuse.NewReadinessHandler(checker).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			var health langfuse.HealthStatus
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &health))
+			assert.Equal(t, test.status, health.Status)
+			assert.Equal(t, []string{"Recent errors detected"}, health.Warnings)
+		})
+	}
+}
+
+func Test_LivenessHandler_ReportsActiveProcessors(t *testing.T) {
+	testCases := []struct {
+		name               string
+		activeProcessors   int
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "processors running",
+			activeProcessors:   2,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"status":"healthy","active_processors":2}`,
+		},
+		{
+			name:               "processors stopped",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       `{"status":"unhealthy","active_processors":0}`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			collector := langfuse.NewMetricsCollector()
+			collector.UpdateActiveProcessors(test.activeProcessors)
+			recorder := httptest.NewRecorder()
+
+			langfuse.NewLivenessHandler(collector).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.JSONEq(t, test.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func Test_MetricsHandler_WritesMetricsAsJSON(t *testing.T) {
+	collector := langfuse.NewMetricsCollector()
+	collector.IncrementEventsQueued()
+	collector.IncrementEventsProcessed()
+	recorder := httptest.NewRecorder()
+
+	langfuse.NewMetricsHandler(collector).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics.json", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var metrics langfuse.Metrics
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &metrics))
+	assert.Equal(t, int64(1), metrics.EventsQueued)
+	assert.Equal(t, int64(1), metrics.EventsProcessed)
+}
+
+type healthCheckerFunc func(ctx context.Context) langfuse.HealthStatus
+
+func (f healthCheckerFunc) CheckHealth(ctx context.Context) langfuse.HealthStatus {
+	return f(ctx)
+}
@@ -0,0 +1,115 @@
+package langfuse
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+)
+
+// defaultHealthProbeTimeout is the health endpoint call timeout used when none is configured
+const defaultHealthProbeTimeout = 5 * time.Second
+
+// percentMultiplier converts a ratio to a percentage
+const percentMultiplier = 100
+
+// apiProbeComponent is the name of the health endpoint probe in HealthStatus.Components
+const apiProbeComponent = "api_probe"
+
+// HealthCheck a custom health check registered with RegisterHealthCheck, a non-nil error marks the component critical.
+// The check must respect the context, which is the one passed to CheckHealth.
+type HealthCheck func(ctx context.Context) error
+
+// HealthThresholds the limits above which CheckHealth reports a warning or an unhealthy client
+type HealthThresholds struct {
+	// QueueUtilizationWarning the queue utilization ratio above which a warning is reported
+	QueueUtilizationWarning float64
+	// QueueUtilizationCritical the queue utilization ratio above which the client is unhealthy
+	QueueUtilizationCritical float64
+	// ErrorRateWarning the HTTP request error rate above which a warning is reported
+	ErrorRateWarning float64
+	// ErrorRateCritical the HTTP request error rate above which the client is unhealthy
+	ErrorRateCritical float64
+	// ResponseTimeP99Warning the p99 HTTP response time above which a warning is reported
+	ResponseTimeP99Warning time.Duration
+	// ResponseTimeP99Critical the p99 HTTP response time above which the client is unhealthy
+	ResponseTimeP99Critical time.Duration
+	// QueueLatencyP99Warning the p99 time from adding an event until it was sent above which a warning is reported
+	QueueLatencyP99Warning time.Duration
+	// LatencyWindow the period the p99 thresholds are evaluated on, the p99 is computed from the requests and events
+	// of the last one to two windows so that the health recovers once latencies are back to normal. 5 minutes when not set.
+	LatencyWindow time.Duration
+}
+
+// DefaultHealthThresholds returns the thresholds used when none are configured
+func DefaultHealthThresholds() HealthThresholds {
+	return HealthThresholds{
+		QueueUtilizationWarning:  queueUtilizationWarning,
+		QueueUtilizationCritical: queueUtilizationCritical,
+		ErrorRateWarning:         errorRateWarning,
+		ErrorRateCritical:        errorRateCritical,
+		ResponseTimeP99Warning:   responseTimeP99Warning,
+		ResponseTimeP99Critical:  responseTimeP99Critical,
+		QueueLatencyP99Warning:   queueLatencyP99Warning,
+		LatencyWindow:            recentIssueWindow,
+	}
+}
+
+// NewHealthThresholds returns the thresholds of the config, using the default for every threshold that is not set
+func NewHealthThresholds(cfg *config.Langfuse) HealthThresholds {
+	thresholds := DefaultHealthThresholds()
+	if cfg.HealthQueueUtilizationWarning > 0 {
+		thresholds.QueueUtilizationWarning = cfg.HealthQueueUtilizationWarning
+	}
+	if cfg.HealthQueueUtilizationCritical > 0 {
+		thresholds.QueueUtilizationCritical = cfg.HealthQueueUtilizationCritical
+	}
+	if cfg.HealthErrorRateWarning > 0 {
+		thresholds.ErrorRateWarning = cfg.HealthErrorRateWarning
+	}
+	if cfg.HealthErrorRateCritical > 0 {
+		thresholds.ErrorRateCritical = cfg.HealthErrorRateCritical
+	}
+	if cfg.HealthResponseTimeP99Warning > 0 {
+		thresholds.ResponseTimeP99Warning = cfg.HealthResponseTimeP99Warning
+	}
+	if cfg.HealthResponseTimeP99Critical > 0 {
+		thresholds.ResponseTimeP99Critical = cfg.HealthResponseTimeP99Critical
+	}
+	if cfg.HealthQueueLatencyP99Warning > 0 {
+		thresholds.QueueLatencyP99Warning = cfg.HealthQueueLatencyP99Warning
+	}
+	return thresholds
+}
+
+// addComponent records the result of a component check, a failed check marks the client unhealthy
+func (h *HealthStatus) addComponent(name string, err error) {
+	if h.Components == nil {
+		h.Components = make(map[string]ComponentHealthValue)
+	}
+
+	if err == nil {
+		h.Components[name] = cmpHealthHealthy
+		return
+	}
+
+	h.Components[name] = cmpHealthCritical
+	h.Errors = append(h.Errors, fmt.Sprintf("Health check %s failed: %v", name, err))
+	h.Status = healthStatusUnhealthy
+}
+
+// clone returns a copy of the status that shares no components, errors or warnings with it
+func (h HealthStatus) clone() HealthStatus {
+	h.Components = maps.Clone(h.Components)
+	h.Errors = slices.Clone(h.Errors)
+	h.Warnings = slices.Clone(h.Warnings)
+	return h
+}
+
+// percent formats a ratio as a percentage for health messages
+func percent(ratio float64) string {
+	return fmt.Sprintf("%.4g%%", ratio*percentMultiplier)
+}
@@ -0,0 +1,126 @@
+package langfuse_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/mock"
+)
+
+func Test_CheckHealth_WithProbeAndRegisteredChecks(t *testing.T) {
+	testCases := []struct {
+		name               string
+		probeStatusCode    int
+		checkErr           error
+		expectedStatus     langfuse.HealthStatusValue
+		expectedComponents map[string]langfuse.ComponentHealthValue
+	}{
+		{
+			name:            "all checks pass",
+			probeStatusCode: http.StatusOK,
+			expectedStatus:  "healthy",
+			expectedComponents: map[string]langfuse.ComponentHealthValue{
+				"api_probe": "healthy",
+				"database":  "healthy",
+			},
+		},
+		{
+			name:            "health endpoint unavailable",
+			probeStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:  "unhealthy",
+			expectedComponents: map[string]langfuse.ComponentHealthValue{
+				"api_probe": "critical",
+				"database":  "healthy",
+			},
+		},
+		{
+			name:            "registered check fails",
+			probeStatusCode: http.StatusOK,
+			checkErr:        errors.New("connection refused"),
+			expectedStatus:  "unhealthy",
+			expectedComponents: map[string]langfuse.ComponentHealthValue{
+				"api_probe": "healthy",
+				"database":  "critical",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.HealthProbe = true
+			var probedPath string
+			httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				probedPath = request.Method + " " + request.URL.Path
+				return &http.Response{StatusCode: test.probeStatusCode, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+			})}
+			subject := langfuse.NewWithClient(cfg, httpClient)
+			defer func() { _ = subject.Stop(context.TODO()) }()
+
+			type ctxKey struct{}
+			ctx := context.WithValue(context.TODO(), ctxKey{}, "readiness")
+			subject.RegisterHealthCheck("database", func(checkCtx context.Context) error {
+				assert.Equal(t, "readiness", checkCtx.Value(ctxKey{}))
+				return test.checkErr
+			})
+
+			health := subject.CheckHealth(ctx)
+
+			require.Equal(t, "GET /api/public/health", probedPath)
+			assert.Equal(t, test.expectedStatus, health.Status)
+			for name, expected := range test.expectedComponents {
+				assert.Equal(t, expected, health.Components[name], name)
+			}
+			assert.Equal(t, health.Status, subject.GetHealthStatus().Status)
+		})
+	}
+}
+
+func Test_CheckHealth_WhenClientIsNotPinger_SkipsProbe(t *testing.T) {
+	cfg := testConfig()
+	cfg.HealthProbe = true
+	subject := langfuse.NewWithClient(cfg, &http.Client{}, langfuse.WithClient(&stubClient{}))
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	health := subject.CheckHealth(context.TODO())
+
+	assert.NotContains(t, health.Components, "api_probe")
+}
+
+func Test_CheckHealth_ConcurrentWithGetHealthStatus(t *testing.T) {
+	subject := langfuse.NewWithClient(testConfig(), &http.Client{})
+	defer func() { _ = subject.Stop(context.TODO()) }()
+	subject.RegisterHealthCheck("database", func(context.Context) error { return nil })
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				health := subject.CheckHealth(context.TODO())
+				health.Components["caller"] = "healthy"
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_, err := json.Marshal(subject.GetHealthStatus())
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.NotContains(t, subject.GetHealthStatus().Components, "caller")
+}
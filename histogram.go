@@ -0,0 +1,159 @@
+package langfuse
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	quantileP50 = 0.5  // Median
+	quantileP90 = 0.9  // 90th percentile
+	quantileP99 = 0.99 // 99th percentile
+)
+
+var (
+	// responseTimeBuckets are the upper bounds in seconds of the HTTP response time histogram
+	responseTimeBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// batchSizeBuckets are the upper bounds in events of the batch size histogram
+	batchSizeBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000}
+	// eventSizeBuckets are the upper bounds in bytes of the event payload size histogram
+	eventSizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+	// queueLatencyBuckets are the upper bounds in seconds of the queue latency histogram
+	queueLatencyBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+)
+
+// HistogramBucket a cumulative histogram bucket, Count is the number of observations less than or equal to UpperBound
+type HistogramBucket struct {
+	UpperBound float64 `json:"upper_bound"`
+	Count      uint64  `json:"count"`
+}
+
+// Histogram a snapshot of a bucketed histogram, observations above the last upper bound are only included in Count.
+// P50, P90 and P99 are estimated from the buckets, see Quantile.
+type Histogram struct {
+	Buckets []HistogramBucket `json:"buckets"`
+	Count   uint64            `json:"count"`
+	Sum     float64           `json:"sum"`
+	P50     float64           `json:"p50"`
+	P90     float64           `json:"p90"`
+	P99     float64           `json:"p99"`
+}
+
+// Quantile estimates the q-quantile (0 <= q <= 1) of the observations by linear interpolation within the
+// bucket the quantile falls in, the same way Prometheus' histogram_quantile does. Quantiles that fall above
+// the last upper bound return the last upper bound. Returns 0 when there are no observations.
+func (h Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 || len(h.Buckets) == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.Count)
+	lowerBound, lowerCount := 0.0, uint64(0)
+	for _, bucket := range h.Buckets {
+		if float64(bucket.Count) >= rank && bucket.Count > lowerCount {
+			fraction := (rank - float64(lowerCount)) / float64(bucket.Count-lowerCount)
+			return lowerBound + (bucket.UpperBound-lowerBound)*fraction
+		}
+		lowerBound, lowerCount = bucket.UpperBound, bucket.Count
+	}
+	return lowerBound
+}
+
+// histogram accumulates observations into fixed buckets, it is not safe for concurrent use on its own
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// newHistogram creates a histogram with the given bucket upper bounds in ascending order
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1), // extra bucket for +Inf
+	}
+}
+
+// observe adds a value to the histogram
+func (h *histogram) observe(value float64) {
+	index, _ := slices.BinarySearch(h.bounds, value)
+	h.counts[index]++
+	h.count++
+	h.sum += value
+}
+
+// merge adds the observations of another histogram with the same bounds
+func (h *histogram) merge(other *histogram) {
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+// snapshot returns the histogram with cumulative bucket counts
+func (h *histogram) snapshot() Histogram {
+	buckets := make([]HistogramBucket, 0, len(h.bounds))
+	var cumulative uint64
+	for i, upperBound := range h.bounds {
+		cumulative += h.counts[i]
+		buckets = append(buckets, HistogramBucket{UpperBound: upperBound, Count: cumulative})
+	}
+	snapshot := Histogram{Buckets: buckets, Count: h.count, Sum: h.sum}
+	snapshot.P50 = snapshot.Quantile(quantileP50)
+	snapshot.P90 = snapshot.Quantile(quantileP90)
+	snapshot.P99 = snapshot.Quantile(quantileP99)
+	return snapshot
+}
+
+// windowedHistogram accumulates the observations of the current and the previous window, so that a snapshot covers
+// the observations of the last one to two windows rather than the lifetime of the process. It is not safe for
+// concurrent use on its own.
+type windowedHistogram struct {
+	bounds    []float64
+	current   *histogram
+	previous  *histogram
+	startedAt time.Time
+}
+
+// newWindowedHistogram creates a windowed histogram with the given bucket upper bounds in ascending order
+func newWindowedHistogram(bounds []float64, now time.Time) *windowedHistogram {
+	return &windowedHistogram{
+		bounds:    bounds,
+		current:   newHistogram(bounds),
+		previous:  newHistogram(bounds),
+		startedAt: now,
+	}
+}
+
+// observe adds a value to the current window
+func (w *windowedHistogram) observe(value float64, now time.Time, window time.Duration) {
+	w.advance(now, window)
+	w.current.observe(value)
+}
+
+// snapshot returns the observations of the current and the previous window with cumulative bucket counts
+func (w *windowedHistogram) snapshot(now time.Time, window time.Duration) Histogram {
+	w.advance(now, window)
+	merged := newHistogram(w.bounds)
+	merged.merge(w.previous)
+	merged.merge(w.current)
+	return merged.snapshot()
+}
+
+// advance starts a new window once the current one elapsed, discarding the previous one
+func (w *windowedHistogram) advance(now time.Time, window time.Duration) {
+	elapsed := now.Sub(w.startedAt)
+	if elapsed < window {
+		return
+	}
+
+	w.previous = w.current
+	if elapsed >= 2*window {
+		// Nothing was observed during the last window
+		w.previous = newHistogram(w.bounds)
+	}
+	w.current = newHistogram(w.bounds)
+	w.startedAt = now
+}
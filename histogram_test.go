@@ -0,0 +1,39 @@
+package langfuse_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+)
+
+func Test_Histogram_Quantile(t *testing.T) {
+	histogram := langfuse.Histogram{
+		Buckets: []langfuse.HistogramBucket{
+			{UpperBound: 1, Count: 50},
+			{UpperBound: 2, Count: 90},
+			{UpperBound: 4, Count: 98},
+		},
+		Count: 100,
+	}
+
+	testCases := []struct {
+		name      string
+		histogram langfuse.Histogram
+		quantile  float64
+		expected  float64
+	}{
+		{name: "median at the first bucket bound", histogram: histogram, quantile: 0.5, expected: 1},
+		{name: "interpolated within the first bucket", histogram: histogram, quantile: 0.25, expected: 0.5},
+		{name: "interpolated within a later bucket", histogram: histogram, quantile: 0.7, expected: 1.5},
+		{name: "above the last bucket bound", histogram: histogram, quantile: 0.99, expected: 4},
+		{name: "no observations", histogram: langfuse.Histogram{}, quantile: 0.99, expected: 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.InDelta(t, test.expected, test.histogram.Quantile(test.quantile), 1e-9)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package langfuse
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
@@ -15,8 +16,14 @@ import (
 	"github.com/bdpiprava/GoLangfuse/types"
 )
 
-// maxParallelItem is the maximum number of items that can be processed in parallel.
-const maxParallelItem = 512
+const (
+	// defaultQueueCapacity is the queue capacity used when none is configured.
+	defaultQueueCapacity = 512
+	// defaultEnqueueTimeout is the enqueue timeout used by the block_with_timeout policy when none is configured.
+	defaultEnqueueTimeout = 100 * time.Millisecond
+	// batchEnvelopeBytes is the size of the ingestion request around the batched events, i.e. {"batch":[]}
+	batchEnvelopeBytes = 12
+)
 
 // Langfuse an interface to send ingestion events to langfuse in async manner
 // Event is added to the queue and then processor is sending it to the langfuse
@@ -25,63 +32,166 @@ type Langfuse interface {
 	Add(event types.LangfuseEvent) *uuid.UUID
 	// AddEvent adds event to the channel and returns the event unique ID, generating one if missing.
 	AddEvent(ctx context.Context, event types.LangfuseEvent) *uuid.UUID
+	// AddEventWithResult adds event to the channel and returns a Delivery to wait for the outcome of the event.
+	AddEventWithResult(ctx context.Context, event types.LangfuseEvent) *Delivery
+	// StartTrace starts a new trace and returns a context carrying it, the trace is added when the handle ends
+	StartTrace(ctx context.Context, name string) (context.Context, *TraceHandle)
+	// StartSpan starts a span linked to the trace and observation in the context, a trace is created when there is none.
+	// The returned context carries the span so that nested observations are linked to it.
+	StartSpan(ctx context.Context, name string) (context.Context, *SpanHandle)
+	// StartGeneration starts a generation linked to the trace and observation in the context, a trace is created when there is none.
+	// The returned context carries the generation so that nested observations are linked to it.
+	StartGeneration(ctx context.Context, name string) (context.Context, *GenerationHandle)
+	// Flush sends the queued events and the batches of all processors, returning once they were sent or the context expired.
+	// Unlike Stop, the service keeps accepting and processing events afterwards.
+	Flush(ctx context.Context) error
 	// Stop gracefully shuts down the service and flushes remaining events
 	Stop(ctx context.Context) error
 	// GetMetrics returns current performance metrics
 	GetMetrics() Metrics
 	// GetHealthStatus returns current health status
 	GetHealthStatus() HealthStatus
-	// CheckHealth performs health checks and returns status
+	// CheckHealth performs health checks and returns status.
+	// The health endpoint probe (when enabled) and the registered health checks run within the context.
 	CheckHealth(ctx context.Context) HealthStatus
+	// RegisterHealthCheck adds a check reported as a component of CheckHealth, replacing a check with the same name
+	RegisterHealthCheck(name string, check HealthCheck)
+}
+
+// serviceState the lifecycle state of the langfuse service
+type serviceState int
+
+const (
+	// stateRunning events are accepted and processed
+	stateRunning serviceState = iota
+	// stateStopping Stop was called, queued events are being flushed and new events are rejected
+	stateStopping
+	// stateStopped all processors exited, new events are rejected
+	stateStopped
+)
+
+// flushRequest asks a processor to send its batch and the queued events, the processor replies on done
+// with the number of events it still holds back
+type flushRequest struct {
+	done chan<- int
 }
 
 type eventChanItem struct {
-	ctx   context.Context
-	event types.LangfuseEvent
+	ctx        context.Context
+	event      types.LangfuseEvent
+	project    *project
+	size       int
+	enqueuedAt time.Time
+	// resend is set once the event was handed to the client, e.g. when it is held back by the circuit breaker
+	resend bool
 }
 
 type langfuseService struct {
-	client           Client
-	config           *config.Langfuse
-	eventChannel     chan eventChanItem
-	stopChannel      chan struct{}
-	wg               sync.WaitGroup
-	metricsCollector *MetricsCollector
+	*serviceCore
+	registry        projectRegistry
+	defaultProject  *project
+	eventChannel    chan eventChanItem
+	queueCapacity   int
+	stopChannel     chan struct{}
+	stoppingChannel chan struct{}
+	wg              sync.WaitGroup
+	stateMu         sync.RWMutex
+	state           serviceState
+	adding          sync.WaitGroup
+	spool           *spool
+	replayStop      chan struct{}
+	replayWg        sync.WaitGroup
+	flushChannels   []chan flushRequest
+	processorsDone  chan struct{}
 }
 
-// New initialise new Langfuse instance for given config with background event processors
+// New initialise new Langfuse instance for given config with background event processors, see NewWithClient.
+// The process exits when the config is invalid, use NewE to handle the error instead.
 func New(config *config.Langfuse) Langfuse {
-	if err := config.Validate(); err != nil {
+	service, err := NewE(config)
+	if err != nil {
 		logger := logger.FromContext(context.Background())
 		logger.Fatalf("invalid langfuse configuration: %v", err)
 	}
+	return service
+}
 
-	optimizedClient := NewOptimizedHTTPClient(config)
-	return NewWithClient(config, optimizedClient)
+// NewE initialise new Langfuse instance for given config like New, returning an error when the config is invalid.
+// The error is an *Error with code INVALID_CONFIG, detailing the invalid field and the reason.
+func NewE(config *config.Langfuse) (Langfuse, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	if !config.IsEnabled() {
+		return NewNoop(), nil
+	}
+	return NewWithClientE(config, NewOptimizedHTTPClient(config))
 }
 
-// NewWithClient initialise new Langfuse instance with background event processors
-func NewWithClient(config *config.Langfuse, customHTTPClient *http.Client) Langfuse {
-	if err := config.Validate(); err != nil {
+// NewWithClient initialise new Langfuse instance with background event processors,
+// or one sending events on the caller's goroutine when SyncMode is configured, see NewSync.
+// A no-op instance is returned when Langfuse is disabled in the config, see NewNoop.
+// The process exits when the config is invalid, use NewWithClientE to handle the error instead.
+func NewWithClient(config *config.Langfuse, customHTTPClient *http.Client, opts ...Option) Langfuse {
+	service, err := NewWithClientE(config, customHTTPClient, opts...)
+	if err != nil {
 		logger := logger.FromContext(context.Background())
 		logger.Fatalf("invalid langfuse configuration: %v", err)
 	}
+	return service
+}
 
-	metricsCollector := NewMetricsCollector()
+// NewWithClientE initialise new Langfuse instance like NewWithClient, returning an error when the config is invalid.
+// The error is an *Error with code INVALID_CONFIG, detailing the invalid field and the reason.
+func NewWithClientE(config *config.Langfuse, customHTTPClient *http.Client, opts ...Option) (Langfuse, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !config.IsEnabled():
+		return NewNoop(), nil
+	case config.SyncMode:
+		return newSyncService(config, customHTTPClient, opts), nil
+	default:
+		return newLangfuseService(config, customHTTPClient, opts), nil
+	}
+}
+
+// newLangfuseService creates the service for a valid config and starts its background event processors
+func newLangfuseService(config *config.Langfuse, customHTTPClient *http.Client, opts []Option) *langfuseService {
+	core := newServiceCore(config, customHTTPClient, opts)
+	metricsCollector := core.metricsCollector
+	capacity := queueCapacity(config)
+
+	// The default project records its own metrics, the health probe calls its API
+	defaultProject := newProject(DefaultProject, config, customHTTPClient, metricsCollector)
+	if core.customClient != nil {
+		defaultProject.client = core.customClient
+	}
+	core.client = defaultProject.client
 
 	eventManager := &langfuseService{
-		client:           NewClient(config, customHTTPClient),
-		config:           config,
-		eventChannel:     make(chan eventChanItem, maxParallelItem),
-		stopChannel:      make(chan struct{}),
-		metricsCollector: metricsCollector,
+		serviceCore: core,
+		registry: projectRegistry{
+			projects:   map[string]*project{DefaultProject: defaultProject},
+			httpClient: customHTTPClient,
+		},
+		defaultProject:  defaultProject,
+		eventChannel:    make(chan eventChanItem, capacity),
+		queueCapacity:   capacity,
+		stopChannel:     make(chan struct{}),
+		stoppingChannel: make(chan struct{}),
+		replayStop:      make(chan struct{}),
+		processorsDone:  make(chan struct{}),
 	}
 
 	// Initialize metrics
-	metricsCollector.UpdateQueueMetrics(0, maxParallelItem)
+	metricsCollector.UpdateQueueMetrics(0, capacity)
 	metricsCollector.UpdateActiveProcessors(config.NumberOfEventProcessor)
 
 	eventManager.startBatchProcessors(config.NumberOfEventProcessor)
+	eventManager.startSpool()
 	return eventManager
 }
 
@@ -90,15 +200,160 @@ func (l *langfuseService) Add(event types.LangfuseEvent) *uuid.UUID {
 }
 
 // AddEvent adds event to the channel and returns the event unique ID, generating one if missing.
+// When the queue is full the configured overflow policy decides whether the call blocks or an event is dropped.
+// Events added after Stop are rejected with ErrServiceStopped, events routed to an unknown project with ErrUnknownProject
+// and update events without an ID with ErrInvalidEventID.
 func (l *langfuseService) AddEvent(ctx context.Context, event types.LangfuseEvent) *uuid.UUID {
-	ensureEventID(event)
-	cloned := event.Clone()
-	l.eventChannel <- eventChanItem{ctx: ctx, event: cloned}
-	l.metricsCollector.IncrementEventsQueued()
-	l.metricsCollector.UpdateQueueMetrics(len(l.eventChannel), maxParallelItem)
+	item, err := newEventChanItem(ctx, event)
+	if err != nil {
+		l.rejectEvent(item, err)
+		return nil
+	}
+	l.add(item)
 	return event.GetID()
 }
 
+// newEventChanItem returns the queue item of a copy of the event, generating the event ID if missing.
+// Returns ErrInvalidEventID along with the item for update events without an ID.
+func newEventChanItem(ctx context.Context, event types.LangfuseEvent) (eventChanItem, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err := ensureEventID(event)
+	return eventChanItem{ctx: ctx, event: event.Clone(), enqueuedAt: time.Now()}, err
+}
+
+// add routes the item to its project and adds it to the queue, the outcome is recorded when it is rejected or dropped
+func (l *langfuseService) add(item eventChanItem) {
+	project, err := l.route(item.ctx, item.event)
+	if err != nil {
+		l.rejectEvent(item, err)
+		return
+	}
+	item.project = project
+
+	// Stop closes the channel only once the in-flight calls returned
+	if !l.beginAdd() {
+		l.rejectEvent(item, ErrServiceStopped)
+		return
+	}
+	defer l.adding.Done()
+
+	if err := l.enqueue(item); errors.Is(err, ErrServiceStopped) {
+		l.rejectEvent(item, err)
+		return
+	} else if err != nil {
+		l.dropEvent(item)
+		return
+	}
+
+	project.metrics.IncrementEventsQueued()
+	l.metricsCollector.UpdateQueueMetrics(len(l.eventChannel), l.queueCapacity)
+}
+
+// beginAdd registers an in-flight AddEvent call, false when Stop was called
+func (l *langfuseService) beginAdd() bool {
+	l.stateMu.RLock()
+	defer l.stateMu.RUnlock()
+	if l.state != stateRunning {
+		return false
+	}
+	l.adding.Add(1)
+	return true
+}
+
+// enqueue adds the item to the event channel following the configured overflow policy.
+// Returns ErrQueueFull when the item must be dropped and ErrServiceStopped when Stop was called while waiting for space.
+func (l *langfuseService) enqueue(item eventChanItem) error {
+	switch l.config.OverflowPolicy {
+	case config.OverflowDropNewest:
+		select {
+		case l.eventChannel <- item:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+
+	case config.OverflowDropOldest:
+		for {
+			select {
+			case l.eventChannel <- item:
+				return nil
+			default:
+			}
+
+			// Queue is full, make space by dropping the oldest event
+			select {
+			case oldest := <-l.eventChannel:
+				l.dropEvent(oldest)
+			default:
+			}
+		}
+
+	case config.OverflowBlockWithTimeout:
+		// Only the enqueue timeout decides, an event is not dropped because the request context of the caller is done
+		select {
+		case l.eventChannel <- item:
+			return nil
+		default:
+		}
+
+		timer := time.NewTimer(enqueueTimeout(l.config))
+		defer timer.Stop()
+		select {
+		case l.eventChannel <- item:
+			return nil
+		case <-timer.C:
+			return ErrQueueFull
+		case <-l.stoppingChannel:
+			return ErrServiceStopped
+		}
+
+	default:
+		select {
+		case l.eventChannel <- item:
+			return nil
+		case <-l.stoppingChannel:
+			return ErrServiceStopped
+		}
+	}
+}
+
+// rejectEvent records an event that was added after the service was stopped or routed to an unknown project
+func (l *langfuseService) rejectEvent(item eventChanItem, err error) {
+	log := logger.FromContext(item.ctx)
+	log.WithError(err).Warnf("rejecting langfuse event %v", item.event.GetID())
+	metrics := l.metricsCollector
+	if item.project != nil {
+		metrics = item.project.metrics
+	}
+	metrics.IncrementEventsRejected(err)
+	l.eventDropped(item.event, err)
+}
+
+// dropEvent records an event that was discarded due to queue overflow
+func (l *langfuseService) dropEvent(item eventChanItem) {
+	log := logger.FromContext(item.ctx)
+	log.Warnf("langfuse event queue is full, dropping event %v", item.event.GetID())
+	item.project.metrics.IncrementEventsDropped()
+	l.eventDropped(item.event, ErrQueueFull)
+}
+
+// StartTrace starts a new trace and returns a context carrying it, the trace is added when the handle ends
+func (l *langfuseService) StartTrace(ctx context.Context, name string) (context.Context, *TraceHandle) {
+	return startTrace(ctx, l, name)
+}
+
+// StartSpan starts a span linked to the trace and observation in the context
+func (l *langfuseService) StartSpan(ctx context.Context, name string) (context.Context, *SpanHandle) {
+	return startSpan(ctx, l, name)
+}
+
+// StartGeneration starts a generation linked to the trace and observation in the context
+func (l *langfuseService) StartGeneration(ctx context.Context, name string) (context.Context, *GenerationHandle) {
+	return startGeneration(ctx, l, name)
+}
+
 // startBatchProcessors start the background batch processors
 func (l *langfuseService) startBatchProcessors(count int) {
 	if count <= 0 {
@@ -106,41 +361,141 @@ func (l *langfuseService) startBatchProcessors(count int) {
 		return
 	}
 
+	l.flushChannels = make([]chan flushRequest, count)
 	for i := range count {
+		l.flushChannels[i] = make(chan flushRequest)
 		l.wg.Add(1)
 		go func(processorID int) {
 			defer l.wg.Done()
 			l.processBatches(processorID)
 		}(i)
 	}
+	go func() {
+		l.wg.Wait()
+		close(l.processorsDone)
+	}()
 }
 
-// processBatches processes events in batches with timeout-based flushing
+// processBatches processes events in batches per project with timeout-based flushing
 func (l *langfuseService) processBatches(processorID int) {
 	log := logger.FromContext(context.Background())
 	log.Debugf("Starting batch processor %d", processorID)
 
-	var batch []eventChanItem
-	ticker := time.NewTicker(l.config.BatchTimeout)
+	batches := make(map[*project]*projectBatch)
+	// draining is set once Stop closed the queue or timed out, held back events are then no longer kept
+	draining := false
+	tickInterval := l.config.BatchTimeout
+	ticker := time.NewTicker(tickInterval)
 	defer ticker.Stop()
 
-	flushBatch := func() {
-		if len(batch) == 0 {
+	flushBatch := func(batch *projectBatch) {
+		if len(batch.items) == 0 {
 			return
 		}
 
-		// Group events by context (for better tracing)
-		contextGroups := make(map[context.Context][]types.LangfuseEvent)
-		for _, item := range batch {
-			contextGroups[item.ctx] = append(contextGroups[item.ctx], item.event)
+		// Group events by context (for better tracing), events sent before are sent as a resend
+		type sendGroup struct {
+			ctx    context.Context
+			resend bool
+		}
+		contextGroups := make(map[sendGroup][]eventChanItem)
+		for _, item := range batch.items {
+			group := sendGroup{ctx: item.ctx, resend: item.resend}
+			contextGroups[group] = append(contextGroups[group], item)
 		}
 
-		// Send each context group as a batch
-		for ctx, events := range contextGroups {
-			l.sendBatch(ctx, events)
+		// Send each context group as a batch, keeping the events held back by the circuit breaker
+		var held []eventChanItem
+		for group, items := range contextGroups {
+			events := make([]types.LangfuseEvent, 0, len(items))
+			for _, item := range items {
+				events = append(events, item.event)
+			}
+			ctx := group.ctx
+			if group.resend {
+				ctx = withResend(ctx)
+			}
+			heldBack := heldItems(items, l.sendBatch(ctx, batch.project, events, !draining))
+			l.recordQueueLatency(batch.project, items, heldBack)
+			held = append(held, heldBack...)
 		}
 
-		batch = batch[:0] // Clear the batch
+		batch.items = held
+		batch.bytes = batchEnvelopeBytes
+		for _, item := range batch.items {
+			batch.bytes += item.size
+		}
+		batch.held = len(batch.items) > 0
+	}
+
+	// appendItem adds a measured item to the batch of its project, flushing the batch when it is full.
+	// While the batch is held back the item waits next to it, so that the queue is still read for other projects.
+	appendItem := func(batch *projectBatch, item eventChanItem) {
+		if batch.held {
+			l.holdItem(batch, item)
+			return
+		}
+
+		// Flush first when the event would push the batch over the configured size in bytes
+		maxBatchBytes := batch.project.config.MaxBatchBytes
+		if maxBatchBytes > 0 && len(batch.items) > 0 && batch.bytes+item.size > maxBatchBytes {
+			flushBatch(batch)
+		}
+
+		batch.items = append(batch.items, item)
+		batch.bytes += item.size
+
+		// Flush batch if it reaches the configured size
+		if len(batch.items) >= batch.project.config.BatchSize {
+			flushBatch(batch)
+		}
+	}
+
+	// flushProject sends the batch and then the events that waited while it was held back, until it is held again
+	flushProject := func(batch *projectBatch) {
+		flushBatch(batch)
+		for !batch.held && len(batch.waiting) > 0 {
+			waiting := batch.waiting
+			batch.waiting = nil
+			for _, item := range waiting {
+				appendItem(batch, item)
+			}
+			flushBatch(batch)
+		}
+	}
+
+	flushAll := func() {
+		for _, batch := range batches {
+			flushProject(batch)
+		}
+	}
+
+	heldEvents := func() int {
+		count := 0
+		for _, batch := range batches {
+			count += len(batch.items) + len(batch.waiting)
+		}
+		return count
+	}
+
+	addItem := func(item eventChanItem) {
+		// Update queue metrics
+		l.metricsCollector.UpdateQueueMetrics(len(l.eventChannel), l.queueCapacity)
+
+		size, err := l.measureEvent(item.project, item.event)
+		if err != nil {
+			logger.FromContext(item.ctx).WithError(err).Errorf("failed to add event %v to batch", item.event.GetID())
+			l.eventFailed(item.project.metrics, item.event, err, 0)
+			return
+		}
+		item.size = size
+
+		batch, found := batches[item.project]
+		if !found {
+			batch = newProjectBatch(item.project)
+			batches[item.project] = batch
+		}
+		appendItem(batch, item)
 	}
 
 	for {
@@ -148,118 +503,637 @@ func (l *langfuseService) processBatches(processorID int) {
 		case item, ok := <-l.eventChannel:
 			if !ok {
 				// Channel closed, flush remaining events and exit
-				flushBatch()
+				draining = true
+				flushAll()
 				log.Debugf("Batch processor %d stopped", processorID)
 				return
 			}
 
-			batch = append(batch, item)
-
-			// Update queue metrics
-			l.metricsCollector.UpdateQueueMetrics(len(l.eventChannel), maxParallelItem)
+			addItem(item)
 
-			// Flush batch if it reaches the configured size
-			if len(batch) >= l.config.BatchSize {
-				flushBatch()
+		case request := <-l.flushChannels[processorID]:
+			// Flush requested, take the queued events without waiting and send them along with the batches
+			for {
+				item, ok := l.receiveQueued()
+				if !ok {
+					break
+				}
+				addItem(item)
 			}
+			flushAll()
+			request.done <- heldEvents()
 
 		case <-ticker.C:
-			// Flush batch on timeout
-			flushBatch()
+			// Flush the batches whose timeout elapsed, this also retries held back batches
+			for project, batch := range batches {
+				batch.ticks++
+				if batch.held || batch.ticks >= batch.ticksPerFlush(tickInterval) {
+					batch.ticks = 0
+					flushProject(batch)
+				}
+				if len(batch.items) == 0 && len(batch.waiting) == 0 {
+					delete(batches, project)
+				}
+			}
 
 		case <-l.stopChannel:
-			// Graceful shutdown requested
-			flushBatch()
-			log.Debugf("Batch processor %d stopped gracefully", processorID)
+			// Stop timed out, flush what this processor holds and exit without draining the queue
+			draining = true
+			flushAll()
+			log.Debugf("Batch processor %d stopped before draining the queue", processorID)
 			return
 		}
 	}
 }
 
-// sendBatch sends a batch of events to Langfuse and logs any issues
-func (l *langfuseService) sendBatch(ctx context.Context, events []types.LangfuseEvent) {
+// holdItem keeps an item read while the batch of its project is held back by the circuit breaker. At most as many
+// events as the queue holds wait per project, beyond that the overflow policy decides which event fails with
+// ErrCircuitOpen: the oldest one with drop_oldest, the new one otherwise. Other projects are not affected.
+func (l *langfuseService) holdItem(batch *projectBatch, item eventChanItem) {
+	if len(batch.items)+len(batch.waiting) < l.queueCapacity {
+		batch.waiting = append(batch.waiting, item)
+		return
+	}
+
+	failed := item
+	if l.config.OverflowPolicy == config.OverflowDropOldest && len(batch.waiting) > 0 {
+		failed = batch.waiting[0]
+		batch.waiting = append(batch.waiting[1:], item)
+	}
+	logger.FromContext(failed.ctx).Warnf("langfuse circuit breaker of project %s is open and too many events are held back, "+
+		"event %v is not sent", batch.project.name, failed.event.GetID())
+	l.eventFailed(batch.project.metrics, failed.event, ErrCircuitOpen, 0)
+}
+
+// receiveQueued returns the next queued event without waiting, false when the queue is empty or closed
+func (l *langfuseService) receiveQueued() (eventChanItem, bool) {
+	select {
+	case item, ok := <-l.eventChannel:
+		return item, ok
+	default:
+		return eventChanItem{}, false
+	}
+}
+
+// heldItems returns the items whose events were held back. Events are matched by instance, the create and update
+// events of an observation share their ID.
+func heldItems(items []eventChanItem, heldEvents []types.LangfuseEvent) []eventChanItem {
+	if len(heldEvents) == 0 {
+		return nil
+	}
+
+	heldBack := make(map[types.LangfuseEvent]bool, len(heldEvents))
+	for _, heldEvent := range heldEvents {
+		heldBack[heldEvent] = true
+	}
+
+	var held []eventChanItem
+	for _, item := range items {
+		if heldBack[item.event] {
+			item.resend = true
+			held = append(held, item)
+		}
+	}
+	return held
+}
+
+// recordQueueLatency records the time since enqueue of the items the processor finished sending
+func (l *langfuseService) recordQueueLatency(project *project, items, held []eventChanItem) {
+	heldBack := make(map[types.LangfuseEvent]bool, len(held))
+	for _, heldItem := range held {
+		heldBack[heldItem.event] = true
+	}
+
+	now := time.Now()
+	for _, item := range items {
+		if !heldBack[item.event] {
+			project.metrics.RecordQueueLatency(now.Sub(item.enqueuedAt))
+		}
+	}
+}
+
+// measureEvent returns the size of the event within an ingestion request and records it in the event size histogram,
+// unless DisableEventSizeMetrics is configured. The event is not serialized when no size is needed, the size is then 0.
+// When MaxBatchBytes is configured for the project, events that do not fit in a batch on their own are rejected
+// with ErrEventValidation.
+func (l *langfuseService) measureEvent(project *project, ingestionEvent types.LangfuseEvent) (int, error) {
+	if project.config.MaxBatchBytes <= 0 && l.config.DisableEventSizeMetrics {
+		// Neither the batch size limit nor the histogram needs the size
+		return 0, nil
+	}
+
+	encodedSize, err := encodedEventSize(ingestionEvent)
+	if err != nil {
+		return 0, err
+	}
+	if !l.config.DisableEventSizeMetrics {
+		project.metrics.RecordEventSize(encodedSize)
+	}
+
+	size := encodedSize + 1 // separating comma
+	if project.config.MaxBatchBytes > 0 && batchEnvelopeBytes+size > project.config.MaxBatchBytes {
+		return 0, ErrEventValidation.WithDetails(map[string]any{
+			"event_id":        ingestionEvent.GetID().String(),
+			"event_bytes":     size,
+			"max_batch_bytes": project.config.MaxBatchBytes,
+			"reason":          "event is larger than the maximum batch size in bytes",
+		})
+	}
+	return size, nil
+}
+
+// sendBatch sends a batch of events to the project and logs any issues.
+// Returns the events held back because the circuit breaker is open, see holdEvents.
+func (l *langfuseService) sendBatch(ctx context.Context, project *project, events []types.LangfuseEvent, hold bool) []types.LangfuseEvent {
 	log := logger.FromContext(ctx)
-	log.Debugf("sending batch of %d events to langfuse", len(events))
+	log.Debugf("sending batch of %d events to langfuse project %s", len(events), project.name)
+	project.metrics.RecordBatchSize(len(events))
 
 	startTime := time.Now()
-	err := l.client.SendBatch(ctx, events)
+	attemptCtx, attempts := withAttemptCounter(ctx)
+	err := project.client.SendBatch(attemptCtx, events)
 	responseTime := time.Since(startTime)
 
+	if err == nil {
+		project.metrics.IncrementBatchesProcessed()
+		project.metrics.RecordHTTPRequest(true, responseTime)
+		// Update processed events count
+		for _, event := range events {
+			l.eventDelivered(project.metrics, event, *attempts)
+		}
+		return nil
+	}
+
+	// The request succeeded but some events were rejected, only those are sent again
+	if failures := failedEvents(err); len(failures) > 0 {
+		project.metrics.IncrementBatchesProcessed()
+		project.metrics.RecordHTTPRequest(true, responseTime)
+		retryable := l.handleRejectedEvents(ctx, project, events, failures, *attempts)
+		return l.sendIndividually(ctx, project, retryable, hold, *attempts)
+	}
+
+	// Sending individually would fail fast as well while the circuit breaker is open
+	if isCircuitOpen(err) {
+		log.WithError(err).Warnf("langfuse circuit breaker is open, batch of %d events not sent", len(events))
+		return l.holdEvents(ctx, project, events, hold, *attempts)
+	}
+
+	log.WithError(err).Errorf("failed to send batch of %d events", len(events))
+	project.metrics.IncrementBatchesFailed(err)
+	project.metrics.RecordHTTPRequest(false, responseTime)
+
+	// Fall back to individual sends on batch failure
+	return l.sendIndividually(ctx, project, events, hold, *attempts)
+}
+
+// sendIndividually sends the events one by one, spooling the ones that failed for a retryable reason.
+// Returns the events held back because the circuit breaker is open, see holdEvents.
+// The attempts made by the preceding batch request are included in the delivery results.
+func (l *langfuseService) sendIndividually(
+	ctx context.Context,
+	project *project,
+	events []types.LangfuseEvent,
+	hold bool,
+	batchAttempts int,
+) []types.LangfuseEvent {
+	log := logger.FromContext(ctx)
+	// The events were handed to the client in the batch request already
+	ctx = withResend(ctx)
+	var undelivered, blocked []types.LangfuseEvent
+	for _, event := range events {
+		individualStart := time.Now()
+		attemptCtx, attempts := withAttemptCounter(ctx)
+		if sendErr := project.client.Send(attemptCtx, event); sendErr != nil {
+			if isCircuitOpen(sendErr) {
+				blocked = append(blocked, event)
+				continue
+			}
+			log.WithError(sendErr).Errorf("failed to send individual event %v", event)
+			project.metrics.RecordHTTPRequest(false, time.Since(individualStart))
+			if l.spools(project) && isRetryable(sendErr) {
+				undelivered = append(undelivered, event)
+				continue
+			}
+			l.eventFailed(project.metrics, event, sendErr, batchAttempts+*attempts)
+		} else {
+			l.eventDelivered(project.metrics, event, batchAttempts+*attempts)
+			project.metrics.RecordHTTPRequest(true, time.Since(individualStart))
+		}
+	}
+	l.spoolEvents(ctx, undelivered, batchAttempts)
+	return l.holdEvents(ctx, project, blocked, hold, batchAttempts)
+}
+
+// holdEvents decides what happens to events not sent because the circuit breaker is open.
+// They are spooled when a spool is configured, otherwise returned to be retried later when hold is set
+// and counted as failed when it is not, e.g. while the service is stopping.
+func (l *langfuseService) holdEvents(ctx context.Context, project *project, events []types.LangfuseEvent, hold bool, attempts int) []types.LangfuseEvent {
+	switch {
+	case len(events) == 0:
+		return nil
+	case l.spools(project):
+		l.spoolEvents(ctx, events, attempts)
+		return nil
+	case hold:
+		return events
+	}
+
+	logger.FromContext(ctx).Errorf("langfuse circuit breaker is open, %d events are not sent", len(events))
+	for _, event := range events {
+		l.eventFailed(project.metrics, event, ErrCircuitOpen, attempts)
+	}
+	return nil
+}
+
+// spools returns whether undeliverable events of the project are spooled, only the default project has a spool
+func (l *langfuseService) spools(project *project) bool {
+	return l.spool != nil && project == l.defaultProject
+}
+
+// handleRejectedEvents counts the accepted events of a partially successful batch and the events rejected for a
+// non-retryable reason. Returns the events rejected for a retryable reason.
+func (l *langfuseService) handleRejectedEvents(
+	ctx context.Context,
+	project *project,
+	events []types.LangfuseEvent,
+	failures []EventFailure,
+	attempts int,
+) []types.LangfuseEvent {
+	log := logger.FromContext(ctx)
+	rejected := failuresByIndex(failures)
+
+	var retryable []types.LangfuseEvent
+	for i, event := range events {
+		failure, found := rejected[i]
+		switch {
+		case !found:
+			l.eventDelivered(project.metrics, event, attempts)
+		case failure.IsRetryable():
+			retryable = append(retryable, event)
+		default:
+			log.WithError(failure.Err()).Errorf("langfuse rejected event %v: %s", failure.EventID, failure.Message)
+			l.eventFailed(project.metrics, event, failure.Err(), attempts)
+		}
+	}
+	return retryable
+}
+
+// spoolEvents persists events of the default project that could not be delivered so that they are replayed once the
+// API recovers. Events that cannot be spooled fail after the given attempts.
+func (l *langfuseService) spoolEvents(ctx context.Context, events []types.LangfuseEvent, attempts int) {
+	if len(events) == 0 {
+		return
+	}
+
+	log := logger.FromContext(ctx)
+	if err := l.spool.Write(events); err != nil {
+		log.WithError(err).Errorf("failed to spool %d undelivered events", len(events))
+		for _, event := range events {
+			l.eventFailed(l.defaultProject.metrics, event, err, attempts)
+		}
+		return
+	}
+	log.Warnf("spooled %d undelivered events for replay", len(events))
+	for _, event := range events {
+		l.eventSpooled(event, attempts)
+	}
+}
+
+// startSpool opens the spool when configured and starts replaying it in the background.
+// Events left by a previous run are replayed immediately.
+func (l *langfuseService) startSpool() {
+	if l.config.SpoolDir == "" {
+		return
+	}
+
+	log := logger.FromContext(context.Background())
+	spool, err := openSpool(l.config.SpoolDir, spoolMaxBytes(l.config), spoolSegmentBytes(l.config), l.metricsCollector)
 	if err != nil {
-		log.WithError(err).Errorf("failed to send batch of %d events", len(events))
-		l.metricsCollector.IncrementBatchesFailed(err)
-		l.metricsCollector.RecordHTTPRequest(false, responseTime)
+		log.WithError(err).Errorf("failed to open langfuse spool in %s, undeliverable events will not be spooled", l.config.SpoolDir)
+		return
+	}
+	l.spool = spool
 
-		// Fall back to individual sends on batch failure
+	l.replayWg.Add(1)
+	go func() {
+		defer l.replayWg.Done()
+		l.replaySpool()
+	}()
+}
+
+// replaySpool replays spooled events on every replay interval until the service stops
+func (l *langfuseService) replaySpool() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-l.replayStop
+		cancel()
+	}()
+
+	log := logger.FromContext(ctx)
+	ticker := time.NewTicker(spoolReplayInterval(l.config))
+	defer ticker.Stop()
+
+	for {
+		replayed, err := l.spool.Replay(ctx, l.config.BatchSize, l.config.MaxBatchBytes, spoolReplayRate(l.config), l.sendSpooledBatch)
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).Warnf("langfuse spool replay stopped after %d events, retrying later", replayed)
+		} else if replayed > 0 {
+			log.Infof("replayed %d spooled events to langfuse", replayed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendSpooledBatch sends a batch read from the spool. Events rejected for a non-retryable reason
+// are counted as failed and removed from the spool, otherwise the error keeps them spooled.
+func (l *langfuseService) sendSpooledBatch(ctx context.Context, events []types.LangfuseEvent) error {
+	project := l.defaultProject
+	startTime := time.Now()
+	// Spooled events were handed to the client before they were spooled
+	attemptCtx, attempts := withAttemptCounter(withResend(ctx))
+	err := project.client.SendBatch(attemptCtx, events)
+	failures := failedEvents(err)
+	project.metrics.RecordHTTPRequest(err == nil || len(failures) > 0, time.Since(startTime))
+
+	if len(failures) > 0 {
+		// Events rejected for a retryable reason go back to the spool
+		l.spoolEvents(ctx, l.handleRejectedEvents(ctx, project, events, failures, *attempts), *attempts)
+		project.metrics.IncrementBatchesProcessed()
+		project.metrics.IncrementEventsReplayed(len(events) - len(failures))
+		return nil
+	}
+
+	if err != nil {
+		if isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		logger.FromContext(ctx).WithError(err).Errorf("discarding %d spooled events rejected by langfuse", len(events))
 		for _, event := range events {
-			individualStart := time.Now()
-			if sendErr := l.client.Send(ctx, event); sendErr != nil {
-				log.WithError(sendErr).Errorf("failed to send individual event %v", event)
-				l.metricsCollector.IncrementEventsFailed(sendErr)
-				l.metricsCollector.RecordHTTPRequest(false, time.Since(individualStart))
-			} else {
-				l.metricsCollector.IncrementEventsProcessed()
-				l.metricsCollector.RecordHTTPRequest(true, time.Since(individualStart))
-			}
+			l.eventFailed(project.metrics, event, err, *attempts)
 		}
-	} else {
-		l.metricsCollector.IncrementBatchesProcessed()
-		l.metricsCollector.RecordHTTPRequest(true, responseTime)
-		// Update processed events count
-		for range events {
-			l.metricsCollector.IncrementEventsProcessed()
+		return nil
+	}
+
+	project.metrics.IncrementBatchesProcessed()
+	project.metrics.IncrementEventsReplayed(len(events))
+	for _, event := range events {
+		l.eventDelivered(project.metrics, event, *attempts)
+	}
+	return nil
+}
+
+// stopSpool stops the background replay and closes the spool, spooled events remain on disk for the next run
+func (l *langfuseService) stopSpool() {
+	close(l.replayStop)
+	l.replayWg.Wait()
+
+	if l.spool == nil {
+		return
+	}
+	if err := l.spool.Close(); err != nil {
+		logger.FromContext(context.Background()).WithError(err).Warn("failed to close langfuse spool")
+	}
+}
+
+// Flush sends the queued events and the batches of all processors without waiting for the batch timeout.
+// Returns nil once every event queued before the call was delivered, the context error when it expired first and
+// ErrServiceStopped after Stop. Events held back by an open circuit breaker are kept and reported with ErrCircuitOpen,
+// events that failed or were only spooled while flushing with ErrFlushIncomplete. The details of both errors count
+// the held_events, failed_events and spooled_events; events that failed concurrently, e.g. while replaying the spool,
+// are included.
+func (l *langfuseService) Flush(ctx context.Context) error {
+	l.stateMu.RLock()
+	running := l.state == stateRunning
+	l.stateMu.RUnlock()
+	if !running {
+		return ErrServiceStopped
+	}
+	failedBefore, spooledBefore := l.failedEvents.Load(), l.spooledEvents.Load()
+
+	done := make(chan int, len(l.flushChannels))
+	for _, flushChannel := range l.flushChannels {
+		select {
+		case flushChannel <- flushRequest{done: done}:
+		case <-l.processorsDone:
+			return ErrServiceStopped
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+
+	held := 0
+	for range l.flushChannels {
+		select {
+		case count := <-done:
+			held += count
+		case <-l.processorsDone:
+			return ErrServiceStopped
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	failed, spooled := int(l.failedEvents.Load()-failedBefore), int(l.spooledEvents.Load()-spooledBefore)
+	details := map[string]any{"held_events": held, "failed_events": failed, "spooled_events": spooled}
+	switch {
+	case held > 0:
+		return ErrCircuitOpen.WithDetails(details)
+	case failed > 0 || spooled > 0:
+		return ErrFlushIncomplete.WithDetails(details)
+	}
+	return nil
 }
 
-// Stop gracefully shuts down the service and flushes remaining events
+// Stop gracefully shuts down the service and flushes remaining events.
+// Processors drain the queue until it is empty or the context expires, calling Stop more than once returns ErrServiceStopped.
 func (l *langfuseService) Stop(ctx context.Context) error {
 	log := logger.FromContext(ctx)
-	log.Info("Stopping Langfuse service...")
-
-	// Signal all processors to stop
-	close(l.stopChannel)
 
-	// Close the event channel to signal no more events
-	close(l.eventChannel)
+	l.stateMu.Lock()
+	if l.state != stateRunning {
+		l.stateMu.Unlock()
+		return ErrServiceStopped
+	}
+	log.Info("Stopping Langfuse service...")
+	l.state = stateStopping
+	close(l.stoppingChannel)
+	l.stateMu.Unlock()
 
-	// Wait for all processors to finish with timeout
-	done := make(chan struct{})
+	// Close the event channel to signal no more events once the in-flight AddEvent calls returned,
+	// calls waiting for space are released by the stopping channel. Processors drain the channel before exiting.
 	go func() {
-		l.wg.Wait()
-		close(done)
+		l.adding.Wait()
+		close(l.eventChannel)
 	}()
 
+	defer l.setState(stateStopped)
+	defer l.abandonDeliveries()
+	defer l.stopSpool()
+
+	// Wait for all processors to finish with timeout
 	select {
-	case <-done:
+	case <-l.processorsDone:
+		l.metricsCollector.UpdateActiveProcessors(0)
 		log.Info("Langfuse service stopped gracefully")
 		return nil
 	case <-ctx.Done():
+		// Signal processors to stop draining the queue
+		close(l.stopChannel)
 		log.Warn("Langfuse service stop timed out")
 		return ctx.Err()
 	}
 }
 
-// GetMetrics returns current performance metrics
-func (l *langfuseService) GetMetrics() Metrics {
-	return l.metricsCollector.GetMetrics()
+func (l *langfuseService) setState(state serviceState) {
+	l.stateMu.Lock()
+	defer l.stateMu.Unlock()
+	l.state = state
+}
+
+// validateConfig returns an *Error with code INVALID_CONFIG when the config is missing or invalid.
+// The details name the first invalid field and list all of them when there are several.
+func validateConfig(cfg *config.Langfuse) error {
+	if cfg == nil {
+		return NewConfigError("config", "must not be nil")
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var fieldErr *config.FieldError
+	if !errors.As(err, &fieldErr) {
+		return ErrInvalidConfig.WithCause(err)
+	}
+
+	configErr := NewConfigError(fieldErr.Field, fieldErr.Reason).WithCause(err)
+	var validationErrs config.ValidationErrors
+	if errors.As(err, &validationErrs) && len(validationErrs) > 1 {
+		configErr.Details["invalid_fields"] = validationErrs.Fields()
+	}
+	return configErr
+}
+
+// healthProbeTimeout returns the configured health endpoint call timeout or the default when not set
+func healthProbeTimeout(cfg *config.Langfuse) time.Duration {
+	if cfg.HealthProbeTimeout > 0 {
+		return cfg.HealthProbeTimeout
+	}
+	return defaultHealthProbeTimeout
+}
+
+// queueCapacity returns the configured queue capacity or the default when not set
+func queueCapacity(cfg *config.Langfuse) int {
+	if cfg.QueueCapacity > 0 {
+		return cfg.QueueCapacity
+	}
+	return defaultQueueCapacity
 }
 
-// GetHealthStatus returns current health status
-func (l *langfuseService) GetHealthStatus() HealthStatus {
-	return l.metricsCollector.GetHealthStatus()
+// enqueueTimeout returns the configured enqueue timeout or the default when not set
+func enqueueTimeout(cfg *config.Langfuse) time.Duration {
+	if cfg.EnqueueTimeout > 0 {
+		return cfg.EnqueueTimeout
+	}
+	return defaultEnqueueTimeout
 }
 
-// CheckHealth performs health checks and returns status
-func (l *langfuseService) CheckHealth(_ context.Context) HealthStatus {
-	return l.metricsCollector.CheckHealth()
+// spoolMaxBytes returns the configured spool size limit or the default when not set
+func spoolMaxBytes(cfg *config.Langfuse) int64 {
+	if cfg.SpoolMaxBytes > 0 {
+		return cfg.SpoolMaxBytes
+	}
+	return defaultSpoolMaxBytes
+}
+
+// spoolSegmentBytes returns the configured spool segment size or the default when not set
+func spoolSegmentBytes(cfg *config.Langfuse) int64 {
+	if cfg.SpoolSegmentBytes > 0 {
+		return cfg.SpoolSegmentBytes
+	}
+	return defaultSpoolSegmentBytes
+}
+
+// spoolReplayInterval returns the configured spool replay interval or the default when not set
+func spoolReplayInterval(cfg *config.Langfuse) time.Duration {
+	if cfg.SpoolReplayInterval > 0 {
+		return cfg.SpoolReplayInterval
+	}
+	return defaultSpoolReplayInterval
+}
+
+// spoolReplayRate returns the configured spool replay rate or the default when not set
+func spoolReplayRate(cfg *config.Langfuse) int {
+	if cfg.SpoolReplayRate > 0 {
+		return cfg.SpoolReplayRate
+	}
+	return defaultSpoolReplayRate
+}
+
+// failuresByIndex returns the failures by the position of the rejected event in the batch
+func failuresByIndex(failures []EventFailure) map[int]EventFailure {
+	rejected := make(map[int]EventFailure, len(failures))
+	for _, failure := range failures {
+		rejected[failure.Index] = failure
+	}
+	return rejected
+}
+
+// failedEvents returns the events rejected by the ingestion API when err describes a partially successful batch
+func failedEvents(err error) []EventFailure {
+	var langfuseErr *Error
+	if errors.As(err, &langfuseErr) {
+		return langfuseErr.FailedEvents()
+	}
+	return nil
 }
 
 // ensureEventID ensures that the IngestionEvent has a unique ID, generating one if missing.
-func ensureEventID(ingestionEvent types.LangfuseEvent) {
+// Returns ErrInvalidEventID for update events without an ID, see validateEventID.
+func ensureEventID(ingestionEvent types.LangfuseEvent) error {
+	if err := validateEventID(ingestionEvent); err != nil {
+		return err
+	}
 	if ingestionEvent.GetID() != nil {
-		return
+		return nil
 	}
 	newID := uuid.New()
 	ingestionEvent.SetID(&newID)
+	return nil
+}
+
+// validateEventID returns ErrInvalidEventID for update events without an ID. An update refers to the observation
+// it updates by its ID, a generated one would create an orphan observation instead.
+func validateEventID(ingestionEvent types.LangfuseEvent) error {
+	if !isUpdateWithoutID(ingestionEvent) {
+		return nil
+	}
+	return ErrInvalidEventID.WithDetails(map[string]any{
+		"event_type": getEventType(ingestionEvent),
+		"reason":     "update events require the ID of the observation they update",
+	})
+}
+
+// isUpdateWithoutID returns whether the event is an update event without an ID
+func isUpdateWithoutID(ingestionEvent types.LangfuseEvent) bool {
+	switch ingestionEvent.(type) {
+	case *types.SpanUpdateEvent, *types.GenerationUpdateEvent:
+		return ingestionEvent.GetID() == nil
+	}
+	return false
+}
+
+// eventID returns the ID of the event, uuid.Nil when it has none
+func eventID(ingestionEvent types.LangfuseEvent) uuid.UUID {
+	if id := ingestionEvent.GetID(); id != nil {
+		return *id
+	}
+	return uuid.Nil
 }
@@ -2,10 +2,14 @@ package langfuse_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -48,3 +52,562 @@ func Test_AddEvent_ShouldCallClientToSendEvents(t *testing.T) {
 	assert.Contains(t, string(body), `"name":"LLM"`)
 	assert.Contains(t, string(body), `"public":false`)
 }
+
+func Test_AddEvent_WhenQueueIsFull_AppliesOverflowPolicy(t *testing.T) {
+	testCases := []struct {
+		name         string
+		policy       config.OverflowPolicy
+		expectedSent string
+	}{
+		{
+			name:         "drop_newest keeps the queued event and drops the new one",
+			policy:       config.OverflowDropNewest,
+			expectedSent: "10000000-0000-0000-0000-000000000002",
+		},
+		{
+			name:         "drop_oldest drops the queued event and keeps the new one",
+			policy:       config.OverflowDropOldest,
+			expectedSent: "10000000-0000-0000-0000-000000000003",
+		},
+		{
+			name:         "block_with_timeout drops the new event once the timeout expires",
+			policy:       config.OverflowBlockWithTimeout,
+			expectedSent: "10000000-0000-0000-0000-000000000002",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.BatchSize = 1
+			cfg.QueueCapacity = 1
+			cfg.OverflowPolicy = test.policy
+			cfg.EnqueueTimeout = time.Millisecond * 10
+			transport := newBlockingTransport()
+			subject := langfuse.NewWithClient(cfg, &http.Client{Transport: transport})
+
+			// First event is picked up by the processor and blocks in the transport
+			subject.Add(traceWithID("10000000-0000-0000-0000-000000000001"))
+			<-transport.started
+			subject.Add(traceWithID("10000000-0000-0000-0000-000000000002"))
+			subject.Add(traceWithID("10000000-0000-0000-0000-000000000003"))
+
+			assert.Equal(t, int64(1), subject.GetMetrics().EventsDropped)
+			assert.Contains(t, subject.CheckHealth(context.TODO()).Warnings, "Events dropped due to queue overflow")
+
+			close(transport.release)
+			require.Eventually(t, func() bool { return len(transport.bodies()) == 2 }, time.Second*5, time.Millisecond*10)
+			assert.Contains(t, transport.bodies()[1], test.expectedSent)
+		})
+	}
+}
+
+func Test_AddEvent_WithBlockWithTimeout_IgnoresRequestContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	testCases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "cancelled context", ctx: cancelled},
+		{name: "nil context", ctx: nil},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.OverflowPolicy = config.OverflowBlockWithTimeout
+			cfg.EnqueueTimeout = time.Millisecond * 10
+			subject := langfuse.NewWithClient(cfg, &http.Client{Transport: newBlockingTransport()})
+
+			for _, id := range []string{"10000000-0000-0000-0000-000000000011", "10000000-0000-0000-0000-000000000012"} {
+				subject.AddEvent(test.ctx, traceWithID(id)) //nolint:staticcheck // a nil context must not panic
+			}
+
+			metrics := subject.GetMetrics()
+			assert.Equal(t, int64(2), metrics.EventsQueued)
+			assert.Zero(t, metrics.EventsDropped)
+		})
+	}
+}
+
+func Test_AddEvent_WhenBatchIsPartiallyAccepted_ResendsOnlyRetryableEvents(t *testing.T) {
+	cfg := testConfig()
+	cfg.BatchSize = 3
+	var mutex sync.Mutex
+	var bodies []string
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(request.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		bodies = append(bodies, string(body))
+
+		response := "{}"
+		if len(bodies) == 1 {
+			envelopes := envelopeIDs(t, body)
+			response = fmt.Sprintf(`{"successes":[{"id":"%s","status":201}],"errors":[`+
+				`{"id":"%s","status":400,"message":"invalid body"},{"id":"%s","status":500,"message":"internal error"}]}`,
+				envelopes["40000000-0000-0000-0000-000000000001"],
+				envelopes["40000000-0000-0000-0000-000000000002"],
+				envelopes["40000000-0000-0000-0000-000000000003"])
+		}
+		return &http.Response{StatusCode: http.StatusMultiStatus, Body: io.NopCloser(strings.NewReader(response))}, nil
+	})}
+	subject := langfuse.NewWithClient(cfg, httpClient)
+
+	subject.Add(traceWithID("40000000-0000-0000-0000-000000000001"))
+	subject.Add(traceWithID("40000000-0000-0000-0000-000000000002"))
+	subject.Add(traceWithID("40000000-0000-0000-0000-000000000003"))
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	require.Len(t, bodies, 2)
+	assert.Contains(t, bodies[1], "40000000-0000-0000-0000-000000000003")
+	assert.NotContains(t, bodies[1], "40000000-0000-0000-0000-000000000001")
+	assert.NotContains(t, bodies[1], "40000000-0000-0000-0000-000000000002")
+
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(2), metrics.EventsProcessed)
+	assert.Equal(t, int64(1), metrics.EventsFailed)
+	assert.Contains(t, metrics.LastError, "CLIENT_ERROR")
+}
+
+func Test_AddEvent_WhenOneOfEventsSharingIDIsRejected_FailsOnlyThatEvent(t *testing.T) {
+	testCases := []struct {
+		name         string
+		rejectedType string
+		acceptedType string
+	}{
+		{name: "update rejected", rejectedType: langfuse.EventTypeSpanUpdate, acceptedType: langfuse.EventTypeSpanCreate},
+		{name: "create rejected", rejectedType: langfuse.EventTypeSpanCreate, acceptedType: langfuse.EventTypeSpanUpdate},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.BatchSize = 2
+			var requests atomic.Int32
+			httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				requests.Add(1)
+				var body struct {
+					Batch []struct {
+						ID   string `json:"id"`
+						Type string `json:"type"`
+					} `json:"batch"`
+				}
+				require.NoError(t, json.NewDecoder(request.Body).Decode(&body))
+				response := "{}"
+				for _, envelope := range body.Batch {
+					if envelope.Type == test.rejectedType {
+						response = fmt.Sprintf(`{"errors":[{"id":"%s","status":400,"message":"invalid body"}]}`, envelope.ID)
+					}
+				}
+				return &http.Response{StatusCode: http.StatusMultiStatus, Body: io.NopCloser(strings.NewReader(response))}, nil
+			})}
+			recorder := &hookRecorder{}
+			subject := langfuse.NewWithClient(cfg, httpClient, langfuse.WithDeliveryHooks(recorder.hooks()))
+			spanID := uuid.MustParse("40000000-0000-0000-0000-000000000011")
+
+			subject.Add(&types.SpanEvent{ID: &spanID, Name: "plan"})
+			subject.Add(&types.SpanUpdateEvent{ID: &spanID, Name: "plan"})
+			require.NoError(t, subject.Stop(context.TODO()))
+
+			assert.Equal(t, int32(1), requests.Load(), "the accepted event is not sent again")
+			delivered, failed := recorder.get("delivered"), recorder.get("failed")
+			require.Len(t, delivered, 1)
+			assert.Equal(t, test.acceptedType, delivered[0].EventType)
+			require.Len(t, failed, 1)
+			assert.Equal(t, test.rejectedType, failed[0].EventType)
+			metrics := subject.GetMetrics()
+			assert.Equal(t, int64(1), metrics.EventsProcessed)
+			assert.Equal(t, int64(1), metrics.EventsFailed)
+		})
+	}
+}
+
+func Test_AddEvent_WithMaxBatchBytes_SplitsBatchesBySize(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxBatchBytes = 1500
+	cfg.DisableRequestCompression = true
+	cfg.BatchTimeout = time.Hour
+	var mutex sync.Mutex
+	var bodies []string
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(request.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		bodies = append(bodies, string(body))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+	subject := langfuse.NewWithClient(cfg, httpClient)
+
+	for _, id := range []string{"50000000-0000-0000-0000-000000000001", "50000000-0000-0000-0000-000000000002", "50000000-0000-0000-0000-000000000003"} {
+		event := traceWithID(id)
+		event.Input = strings.Repeat("a", 400)
+		subject.Add(event)
+	}
+	oversized := traceWithID("50000000-0000-0000-0000-000000000004")
+	oversized.Input = strings.Repeat("a", 2000)
+	subject.Add(oversized)
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	require.Len(t, bodies, 2)
+	for _, body := range bodies {
+		assert.LessOrEqual(t, len(body), cfg.MaxBatchBytes)
+	}
+	assert.Contains(t, bodies[0], "50000000-0000-0000-0000-000000000002")
+	assert.Contains(t, bodies[1], "50000000-0000-0000-0000-000000000003")
+	assert.NotContains(t, strings.Join(bodies, ""), "50000000-0000-0000-0000-000000000004")
+
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(3), metrics.EventsProcessed)
+	assert.Equal(t, int64(1), metrics.EventsFailed)
+	assert.Contains(t, metrics.LastError, langfuse.ErrEventValidation.Code)
+}
+
+func Test_AddEvent_EventSizeMetrics(t *testing.T) {
+	testCases := []struct {
+		name          string
+		maxBatchBytes int
+		disabled      bool
+		expectedCount uint64
+	}{
+		{name: "recorded by default", expectedCount: 1},
+		{name: "not recorded when disabled", disabled: true},
+		{name: "not recorded when disabled with a batch byte limit", maxBatchBytes: 1500, disabled: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.MaxBatchBytes = test.maxBatchBytes
+			cfg.DisableEventSizeMetrics = test.disabled
+			subject := langfuse.NewWithClient(cfg, sequenceClient(http.StatusOK))
+
+			subject.Add(traceWithID("50000000-0000-0000-0000-000000000011"))
+			require.NoError(t, subject.Stop(context.TODO()))
+
+			metrics := subject.GetMetrics()
+			assert.Equal(t, int64(1), metrics.EventsProcessed)
+			assert.Equal(t, test.expectedCount, metrics.EventSizeHistogram.Count)
+		})
+	}
+}
+
+func traceWithID(id string) *types.TraceEvent {
+	eventID := uuid.MustParse(id)
+	return &types.TraceEvent{ID: &eventID, Name: "LLM"}
+}
+
+// blockingTransport holds every request until released, recording the request bodies
+type blockingTransport struct {
+	started  chan struct{}
+	release  chan struct{}
+	once     sync.Once
+	mutex    sync.Mutex
+	recorded []string
+}
+
+func newBlockingTransport() *blockingTransport {
+	return &blockingTransport{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *blockingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(request.Body)
+	b.mutex.Lock()
+	b.recorded = append(b.recorded, string(body))
+	b.mutex.Unlock()
+
+	b.once.Do(func() { close(b.started) })
+	<-b.release
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func (b *blockingTransport) bodies() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]string(nil), b.recorded...)
+}
+
+func Test_AddEvent_AfterStop_RejectsEvent(t *testing.T) {
+	httpClient := &http.Client{}
+	mock.AddMockTransport(t, httpClient)
+	subject := langfuse.NewWithClient(testConfig(), httpClient)
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	id := subject.Add(traceWithID("10000000-0000-0000-0000-000000000001"))
+
+	assert.Equal(t, "10000000-0000-0000-0000-000000000001", id.String())
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(1), metrics.EventsRejected)
+	assert.Equal(t, int64(0), metrics.EventsQueued)
+	assert.Equal(t, langfuse.ErrServiceStopped.Error(), metrics.LastError)
+	assert.ErrorIs(t, subject.Stop(context.TODO()), langfuse.ErrServiceStopped)
+}
+
+func Test_AddEventWithResult_WhenUpdateEventHasNoID_RejectsEvent(t *testing.T) {
+	syncCfg := testConfig()
+	syncCfg.SyncMode = true
+	testCases := []struct {
+		name    string
+		subject func(t *testing.T) langfuse.Langfuse
+	}{
+		{name: "async", subject: func(t *testing.T) langfuse.Langfuse { return langfuse.NewWithClient(testConfig(), unusedHTTPClient(t)) }},
+		{name: "sync", subject: func(t *testing.T) langfuse.Langfuse { return langfuse.NewWithClient(syncCfg, unusedHTTPClient(t)) }},
+		{name: "recorder", subject: func(*testing.T) langfuse.Langfuse { return langfuse.NewRecorder() }},
+		{name: "noop", subject: func(*testing.T) langfuse.Langfuse { return langfuse.NewNoop() }},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			subject := test.subject(t)
+			defer func() { _ = subject.Stop(context.TODO()) }()
+			update := &types.SpanUpdateEvent{Name: "step"}
+
+			id := subject.AddEvent(context.TODO(), update)
+			delivery := subject.AddEventWithResult(context.TODO(), &types.GenerationUpdateEvent{Name: "llm"})
+
+			assert.Nil(t, id)
+			assert.Nil(t, update.ID, "no ID is generated for an update event")
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			result, err := delivery.Wait(ctx)
+			require.NoError(t, err)
+			var langfuseErr *langfuse.Error
+			require.ErrorAs(t, result.Err, &langfuseErr)
+			assert.Equal(t, langfuse.ErrInvalidEventID.Code, langfuseErr.Code)
+			assert.Equal(t, langfuse.EventTypeGenerationUpdate, result.EventType)
+		})
+	}
+}
+
+// unusedHTTPClient returns an HTTP client failing the test when a request is sent
+func unusedHTTPClient(t *testing.T) *http.Client {
+	httpClient := &http.Client{}
+	mock.AddMockTransport(t, httpClient)
+	return httpClient
+}
+
+func Test_Stop_WhenAddEventIsBlocked_HonoursContext(t *testing.T) {
+	cfg := testConfig()
+	cfg.BatchSize = 1
+	cfg.QueueCapacity = 1
+	cfg.OverflowPolicy = config.OverflowBlock
+	transport := newBlockingTransport()
+	defer close(transport.release)
+	subject := langfuse.NewWithClient(cfg, &http.Client{Transport: transport})
+
+	// First event blocks the processor in the transport, the second fills the queue and the third waits for space
+	subject.Add(traceWithID("10000000-0000-0000-0000-000000000021"))
+	<-transport.started
+	subject.Add(traceWithID("10000000-0000-0000-0000-000000000022"))
+	added := make(chan struct{})
+	go func() {
+		defer close(added)
+		subject.Add(traceWithID("10000000-0000-0000-0000-000000000023"))
+	}()
+	time.Sleep(time.Millisecond * 20)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err := subject.Stop(ctx)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		require.Fail(t, "AddEvent is still blocked after Stop")
+	}
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(1), metrics.EventsRejected)
+	assert.Equal(t, langfuse.ErrServiceStopped.Error(), metrics.LastError)
+}
+
+func Test_AddEvent_ConcurrentWithStop_DoesNotPanic(t *testing.T) {
+	cfg := testConfig()
+	cfg.QueueCapacity = 8
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+	subject := langfuse.NewWithClient(cfg, httpClient)
+
+	const producers, eventsPerProducer = 8, 50
+	var wg sync.WaitGroup
+	for range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range eventsPerProducer {
+				subject.Add(&types.TraceEvent{Name: "LLM"})
+			}
+		}()
+	}
+	require.NoError(t, subject.Stop(context.TODO()))
+	wg.Wait()
+
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(producers*eventsPerProducer), metrics.EventsQueued+metrics.EventsRejected)
+	assert.Equal(t, metrics.EventsQueued, metrics.EventsProcessed, "every accepted event should be flushed on stop")
+}
+
+func Test_AddEvent_RecordsLatencyAndSizeHistograms(t *testing.T) {
+	httpClient := &http.Client{Transport: &switchableTransport{statusCode: http.StatusOK}}
+	subject := langfuse.NewWithClient(testConfig(), httpClient)
+
+	subject.Add(traceWithID("60000000-0000-0000-0000-000000000001"))
+	subject.Add(traceWithID("60000000-0000-0000-0000-000000000002"))
+	subject.Add(traceWithID("60000000-0000-0000-0000-000000000003"))
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	metrics := subject.GetMetrics()
+	assert.Equal(t, uint64(3), metrics.EventSizeHistogram.Count)
+	assert.Positive(t, metrics.EventSizeHistogram.P50)
+	assert.Equal(t, uint64(3), metrics.QueueLatencyHistogram.Count)
+	assert.Equal(t, metrics.BatchSizeHistogram.Count, metrics.ResponseTimeHistogram.Count)
+	assert.InDelta(t, 3, metrics.BatchSizeHistogram.Sum, 0)
+}
+
+func Test_Flush_SendsQueuedEventsAndKeepsProcessing(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumberOfEventProcessor = 3
+	cfg.BatchTimeout = time.Hour
+	transport := &switchableTransport{statusCode: http.StatusOK}
+	subject := langfuse.NewWithClient(cfg, &http.Client{Transport: transport})
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	subject.Add(traceWithID("70000000-0000-0000-0000-000000000001"))
+	subject.Add(traceWithID("70000000-0000-0000-0000-000000000002"))
+	require.NoError(t, subject.Flush(context.TODO()))
+
+	assert.Equal(t, int64(2), subject.GetMetrics().EventsProcessed)
+	assert.Contains(t, transport.delivered(), "70000000-0000-0000-0000-000000000001")
+	assert.Contains(t, transport.delivered(), "70000000-0000-0000-0000-000000000002")
+
+	subject.Add(traceWithID("70000000-0000-0000-0000-000000000003"))
+	require.NoError(t, subject.Flush(context.TODO()))
+
+	assert.Equal(t, int64(3), subject.GetMetrics().EventsProcessed)
+	assert.Contains(t, transport.delivered(), "70000000-0000-0000-0000-000000000003")
+}
+
+func Test_Flush_WhenNotCompleted_ReturnsError(t *testing.T) {
+	t.Run("context expires", func(t *testing.T) {
+		transport := newBlockingTransport()
+		subject := langfuse.NewWithClient(testConfig(), &http.Client{Transport: transport})
+		defer func() {
+			close(transport.release)
+			_ = subject.Stop(context.TODO())
+		}()
+
+		subject.Add(traceWithID("70000000-0000-0000-0000-000000000004"))
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*100)
+		defer cancel()
+
+		assert.ErrorIs(t, subject.Flush(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("service stopped", func(t *testing.T) {
+		subject := langfuse.NewWithClient(testConfig(), &http.Client{Transport: &switchableTransport{statusCode: http.StatusOK}})
+		require.NoError(t, subject.Stop(context.TODO()))
+
+		assert.ErrorIs(t, subject.Flush(context.TODO()), langfuse.ErrServiceStopped)
+	})
+
+	testCases := []struct {
+		name            string
+		cfg             *config.Langfuse
+		statusCode      int
+		expectedFailed  int
+		expectedSpooled int
+	}{
+		{name: "event rejected", cfg: testConfig(), statusCode: http.StatusBadRequest, expectedFailed: 1},
+		{name: "event spooled", cfg: spoolConfig(t.TempDir()), statusCode: http.StatusServiceUnavailable, expectedSpooled: 1},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.cfg.BatchTimeout = time.Hour
+			transport := &switchableTransport{statusCode: test.statusCode}
+			subject := langfuse.NewWithClient(test.cfg, &http.Client{Transport: transport})
+			defer func() { _ = subject.Stop(context.TODO()) }()
+
+			subject.Add(traceWithID("70000000-0000-0000-0000-000000000005"))
+			err := subject.Flush(context.TODO())
+
+			var langfuseErr *langfuse.Error
+			require.ErrorAs(t, err, &langfuseErr)
+			assert.Equal(t, langfuse.ErrFlushIncomplete.Code, langfuseErr.Code)
+			assert.Equal(t, test.expectedFailed, langfuseErr.Details["failed_events"])
+			assert.Equal(t, test.expectedSpooled, langfuseErr.Details["spooled_events"])
+
+			transport.setStatusCode(http.StatusOK)
+			subject.Add(traceWithID("70000000-0000-0000-0000-000000000006"))
+			require.NoError(t, subject.Flush(context.TODO()), "earlier outcomes are not reported again")
+		})
+	}
+}
+
+func Test_NewWithClientE_WhenConfigInvalid_ReturnsConfigError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         func() *config.Langfuse
+		expectedField  string
+		expectedReason string
+	}{
+		{
+			name:           "missing config",
+			config:         func() *config.Langfuse { return nil },
+			expectedField:  "config",
+			expectedReason: "must not be nil",
+		},
+		{
+			name: "missing URL",
+			config: func() *config.Langfuse {
+				cfg := testConfig()
+				cfg.URL = ""
+				return cfg
+			},
+			expectedField:  "URL",
+			expectedReason: "non zero value required",
+		},
+		{
+			name: "no event processors",
+			config: func() *config.Langfuse {
+				cfg := testConfig()
+				cfg.NumberOfEventProcessor = 0
+				return cfg
+			},
+			expectedField:  "NumberOfEventProcessor",
+			expectedReason: "must be greater than 0",
+		},
+		{
+			name: "negative health threshold",
+			config: func() *config.Langfuse {
+				cfg := testConfig()
+				cfg.HealthErrorRateWarning = -1
+				return cfg
+			},
+			expectedField:  "HealthErrorRateWarning",
+			expectedReason: "must not be negative",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			subject, err := langfuse.NewWithClientE(test.config(), &http.Client{})
+
+			assert.Nil(t, subject)
+			var langfuseErr *langfuse.Error
+			require.ErrorAs(t, err, &langfuseErr)
+			assert.Equal(t, langfuse.ErrInvalidConfig.Code, langfuseErr.Code)
+			assert.Equal(t, test.expectedField, langfuseErr.Details["field"])
+			assert.Equal(t, test.expectedReason, langfuseErr.Details["reason"])
+		})
+	}
+}
+
+func Test_NewWithClientE_WhenConfigValid_ReturnsService(t *testing.T) {
+	subject, err := langfuse.NewWithClientE(testConfig(), &http.Client{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, subject.GetMetrics().ActiveProcessors)
+	require.NoError(t, subject.Stop(context.TODO()))
+}
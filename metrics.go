@@ -1,6 +1,7 @@
 package langfuse
 
 import (
+	"fmt"
 	"sync"
 	"time"
 )
@@ -30,12 +31,26 @@ const (
 	errorRateWarning         = 0.05 // 5% error rate threshold
 )
 
+// circuitBreakerComponent is the name of the circuit breaker in HealthStatus.Components
+const circuitBreakerComponent = "circuit_breaker"
+
+// Latency health thresholds, applied to the 99th percentile
+const (
+	responseTimeP99Critical = 5 * time.Second  // p99 HTTP response time threshold for critical API health
+	responseTimeP99Warning  = 2 * time.Second  // p99 HTTP response time threshold for API health warning
+	queueLatencyP99Warning  = 30 * time.Second // p99 queue latency threshold for a warning
+)
+
+// recentIssueWindow is how long recent errors and dropped events are reported by health checks
+const recentIssueWindow = 5 * time.Minute
+
 // Metrics contains comprehensive performance and operational metrics for the GoLangfuse client.
 //
 // This struct provides detailed insights into the client's operation including:
-//   - Event processing statistics (processed, queued, failed)
+//   - Event processing statistics (processed, queued, failed, dropped, rejected)
+//   - Spool statistics (spooled, replayed, depth and size)
 //   - Batch processing metrics (batches processed and failed)
-//   - HTTP request performance (total, success, failure counts and response times)
+//   - HTTP request performance (total, success, failure counts, response times and bytes sent)
 //   - Resource utilization (active processors, queue usage)
 //   - Error tracking (last error time and message)
 //
@@ -67,6 +82,28 @@ type Metrics struct {
 	// or sent to the API, even after retries.
 	EventsFailed int64 `json:"events_failed"`
 
+	// EventsDropped is the total number of events that were dropped because
+	// the queue was full, according to the configured overflow policy.
+	EventsDropped int64 `json:"events_dropped"`
+
+	// EventsRejected is the total number of events that were rejected because
+	// they were added after the service was stopped.
+	EventsRejected int64 `json:"events_rejected"`
+
+	// EventsSpooled is the total number of events written to the on-disk spool
+	// because they could not be delivered.
+	EventsSpooled int64 `json:"events_spooled"`
+
+	// EventsReplayed is the total number of spooled events that were
+	// delivered to the Langfuse API by the background replay.
+	EventsReplayed int64 `json:"events_replayed"`
+
+	// SpoolDepth is the current number of events waiting in the on-disk spool.
+	SpoolDepth int64 `json:"spool_depth"`
+
+	// SpoolBytes is the current size of the on-disk spool in bytes.
+	SpoolBytes int64 `json:"spool_bytes"`
+
 	// BatchesProcessed is the total number of event batches successfully
 	// sent to the Langfuse API.
 	BatchesProcessed int64 `json:"batches_processed"`
@@ -87,10 +124,42 @@ type Metrics struct {
 	// (non-2xx status codes, network errors, timeouts).
 	HTTPRequestsFailure int64 `json:"http_requests_failure"`
 
+	// CircuitBreakerState is the current state of the circuit breaker around
+	// the Langfuse API: "closed", "open" or "half_open". Empty when disabled.
+	CircuitBreakerState CircuitState `json:"circuit_breaker_state,omitempty"`
+
+	// HTTPRetries is the number of HTTP requests to the Langfuse API that
+	// were retried after a failed attempt.
+	HTTPRetries int64 `json:"http_retries"`
+
+	// BytesSent is the total number of request body bytes sent to the
+	// Langfuse API, after compression.
+	BytesSent int64 `json:"bytes_sent"`
+
+	// BytesSaved is the total number of request body bytes saved by
+	// compressing request bodies.
+	BytesSaved int64 `json:"bytes_saved"`
+
 	// AverageResponseTime is the rolling average response time for HTTP
 	// requests to the Langfuse API (based on last 100 requests).
 	AverageResponseTime time.Duration `json:"average_response_time"`
 
+	// ResponseTimeHistogram is the distribution of HTTP response times in
+	// seconds across all requests made to the API, including p50/p90/p99.
+	ResponseTimeHistogram Histogram `json:"response_time_histogram"`
+
+	// BatchSizeHistogram is the distribution of the number of events in
+	// each batch sent to the API.
+	BatchSizeHistogram Histogram `json:"batch_size_histogram"`
+
+	// EventSizeHistogram is the distribution of serialized event payload
+	// sizes in bytes.
+	EventSizeHistogram Histogram `json:"event_size_histogram"`
+
+	// QueueLatencyHistogram is the distribution of the time in seconds from
+	// adding an event until the processor finished sending it.
+	QueueLatencyHistogram Histogram `json:"queue_latency_histogram"`
+
 	// TotalResponseTime is the cumulative response time for all HTTP
 	// requests made to the API.
 	TotalResponseTime time.Duration `json:"total_response_time"`
@@ -123,6 +192,10 @@ type Metrics struct {
 	// was successfully processed. Nil if no events have been processed.
 	LastEventProcessedAt *time.Time `json:"last_event_processed_at"`
 
+	// LastEventDroppedAt is the timestamp of when the last event was dropped
+	// due to queue overflow. Nil if no events have been dropped.
+	LastEventDroppedAt *time.Time `json:"last_event_dropped_at"`
+
 	// LastErrorAt is the timestamp of when the last error occurred.
 	// Nil if no errors have occurred.
 	LastErrorAt *time.Time `json:"last_error_at"`
@@ -179,6 +252,10 @@ type HealthStatus struct {
 	// Based on HTTP request success/failure rates.
 	APIHealth ComponentHealthValue `json:"api_health"`
 
+	// Components contains the health of optional components such as the
+	// circuit breaker, keyed by component name.
+	Components map[string]ComponentHealthValue `json:"components,omitempty"`
+
 	// LastHealthCheck is when this health status was last updated.
 	LastHealthCheck time.Time `json:"last_health_check"`
 
@@ -221,6 +298,18 @@ type MetricsCollector struct {
 	startTime        time.Time
 	responseTimes    []time.Duration
 	maxResponseTimes int
+	responseTimeHist *histogram
+	batchSizeHist    *histogram
+	eventSizeHist    *histogram
+	queueLatencyHist *histogram
+	// recentResponseTimes and recentQueueLatency hold the recent observations the health thresholds are evaluated on
+	recentResponseTimes *windowedHistogram
+	recentQueueLatency  *windowedHistogram
+	thresholds          HealthThresholds
+	// parent receives the counters and histograms recorded for a project, see newProjectMetricsCollector
+	parent *MetricsCollector
+	// circuitStates the state of every circuit breaker reporting to the collector, its own and the ones of its projects
+	circuitStates map[*MetricsCollector]CircuitState
 }
 
 // NewMetricsCollector creates a new MetricsCollector with initialized metrics and health status.
@@ -252,9 +341,26 @@ func NewMetricsCollector() *MetricsCollector {
 		startTime:        now,
 		responseTimes:    make([]time.Duration, 0, maxResponseTimeHistory), // Keep last 100 response times
 		maxResponseTimes: maxResponseTimeHistory,
+		responseTimeHist: newHistogram(responseTimeBuckets),
+		batchSizeHist:    newHistogram(batchSizeBuckets),
+		eventSizeHist:    newHistogram(eventSizeBuckets),
+		queueLatencyHist: newHistogram(queueLatencyBuckets),
+
+		recentResponseTimes: newWindowedHistogram(responseTimeBuckets, now),
+		recentQueueLatency:  newWindowedHistogram(queueLatencyBuckets, now),
+		thresholds:          DefaultHealthThresholds(),
 	}
 }
 
+// newProjectMetricsCollector creates the collector of a project routed by the service. Counters, histograms
+// and the circuit breaker state are recorded in the parent as well, so that the service metrics are the totals
+// of all projects, whereas queue, processor and spool metrics are only recorded by the service.
+func newProjectMetricsCollector(parent *MetricsCollector) *MetricsCollector {
+	collector := NewMetricsCollector()
+	collector.parent = parent
+	return collector
+}
+
 // IncrementEventsProcessed increments the processed events counter and updates
 // the last processed timestamp.
 //
@@ -264,6 +370,10 @@ func NewMetricsCollector() *MetricsCollector {
 // The method updates both the EventsProcessed counter and the LastEventProcessedAt
 // timestamp to track processing activity.
 func (mc *MetricsCollector) IncrementEventsProcessed() {
+	if mc.parent != nil {
+		mc.parent.IncrementEventsProcessed()
+	}
+
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
@@ -279,6 +389,10 @@ func (mc *MetricsCollector) IncrementEventsProcessed() {
 //
 // Thread-safe for concurrent access.
 func (mc *MetricsCollector) IncrementEventsQueued() {
+	if mc.parent != nil {
+		mc.parent.IncrementEventsQueued()
+	}
+
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
@@ -296,6 +410,10 @@ func (mc *MetricsCollector) IncrementEventsQueued() {
 //
 // Thread-safe for concurrent access.
 func (mc *MetricsCollector) IncrementEventsFailed(err error) {
+	if mc.parent != nil {
+		mc.parent.IncrementEventsFailed(err)
+	}
+
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
@@ -307,6 +425,100 @@ func (mc *MetricsCollector) IncrementEventsFailed(err error) {
 	}
 }
 
+// IncrementEventsDropped increments the dropped events counter and updates
+// the last dropped timestamp.
+//
+// This method should be called when an event is discarded because the queue
+// is full, as decided by the configured overflow policy.
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) IncrementEventsDropped() {
+	if mc.parent != nil {
+		mc.parent.IncrementEventsDropped()
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.EventsDropped++
+	now := time.Now().UTC()
+	mc.metrics.LastEventDroppedAt = &now
+}
+
+// IncrementEventsRejected increments the rejected events counter and records error details.
+//
+// This method should be called when an event is added after the service was
+// stopped and therefore will never be sent.
+//
+// Parameters:
+//   - err: The error describing why the event was rejected (can be nil)
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) IncrementEventsRejected(err error) {
+	if mc.parent != nil {
+		mc.parent.IncrementEventsRejected(err)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.EventsRejected++
+	now := time.Now().UTC()
+	mc.metrics.LastErrorAt = &now
+	if err != nil {
+		mc.metrics.LastError = err.Error()
+	}
+}
+
+// IncrementEventsSpooled increments the spooled events counter by n.
+//
+// This method should be called when undeliverable events are written to the
+// on-disk spool to be replayed once the API recovers.
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) IncrementEventsSpooled(n int) {
+	if mc.parent != nil {
+		mc.parent.IncrementEventsSpooled(n)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.EventsSpooled += int64(n)
+}
+
+// IncrementEventsReplayed increments the replayed events counter by n.
+//
+// This method should be called when spooled events are successfully delivered
+// to the Langfuse API by the background replay.
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) IncrementEventsReplayed(n int) {
+	if mc.parent != nil {
+		mc.parent.IncrementEventsReplayed(n)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.EventsReplayed += int64(n)
+}
+
+// UpdateSpoolMetrics updates the spool depth and size.
+//
+// Parameters:
+//   - depth: current number of events held in the spool
+//   - size: current size of the spool segments in bytes
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) UpdateSpoolMetrics(depth, size int64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.SpoolDepth = depth
+	mc.metrics.SpoolBytes = size
+}
+
 // IncrementBatchesProcessed increments the processed batches counter.
 //
 // This method should be called each time a batch of events is successfully
@@ -314,6 +526,10 @@ func (mc *MetricsCollector) IncrementEventsFailed(err error) {
 //
 // Thread-safe for concurrent access.
 func (mc *MetricsCollector) IncrementBatchesProcessed() {
+	if mc.parent != nil {
+		mc.parent.IncrementBatchesProcessed()
+	}
+
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
@@ -330,6 +546,10 @@ func (mc *MetricsCollector) IncrementBatchesProcessed() {
 //
 // Thread-safe for concurrent access.
 func (mc *MetricsCollector) IncrementBatchesFailed(err error) {
+	if mc.parent != nil {
+		mc.parent.IncrementBatchesFailed(err)
+	}
+
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
@@ -350,6 +570,7 @@ func (mc *MetricsCollector) IncrementBatchesFailed(err error) {
 //
 // Response Time Tracking:
 //   - Updates total, min, max, and average response times
+//   - Adds the response time to the response time histogram
 //   - Maintains a rolling window of the last 100 response times for average calculation
 //   - Automatically manages the response time history buffer
 //
@@ -366,6 +587,10 @@ func (mc *MetricsCollector) IncrementBatchesFailed(err error) {
 //	duration := time.Since(start)
 //	collector.RecordHTTPRequest(err == nil && resp.StatusCode < 300, duration)
 func (mc *MetricsCollector) RecordHTTPRequest(success bool, responseTime time.Duration) {
+	if mc.parent != nil {
+		mc.parent.RecordHTTPRequest(success, responseTime)
+	}
+
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
@@ -378,6 +603,8 @@ func (mc *MetricsCollector) RecordHTTPRequest(success bool, responseTime time.Du
 
 	// Record response time
 	mc.metrics.TotalResponseTime += responseTime
+	mc.responseTimeHist.observe(responseTime.Seconds())
+	mc.recentResponseTimes.observe(responseTime.Seconds(), time.Now(), mc.latencyWindow())
 
 	if responseTime > mc.metrics.MaxResponseTime {
 		mc.metrics.MaxResponseTime = responseTime
@@ -403,6 +630,154 @@ func (mc *MetricsCollector) RecordHTTPRequest(success bool, responseTime time.Du
 	}
 }
 
+// UpdateCircuitBreakerState records the current state of the circuit breaker.
+//
+// This method is called by the client whenever the circuit breaker changes state. The service metrics
+// report the worst state of the circuit breakers of all projects, so that one project closing its circuit
+// breaker does not hide the open circuit breaker of another one.
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) UpdateCircuitBreakerState(state CircuitState) {
+	if mc.parent != nil {
+		mc.parent.recordCircuitState(mc, state)
+	}
+	mc.recordCircuitState(mc, state)
+}
+
+// recordCircuitState records the state of the circuit breaker reporting to the given collector
+// and reports the worst state of all circuit breakers
+func (mc *MetricsCollector) recordCircuitState(breaker *MetricsCollector, state CircuitState) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.circuitStates == nil {
+		mc.circuitStates = make(map[*MetricsCollector]CircuitState)
+	}
+	mc.circuitStates[breaker] = state
+	mc.metrics.CircuitBreakerState = mc.worstCircuitState()
+}
+
+// worstCircuitState returns the most severe state of the circuit breakers, must be called with the lock held
+func (mc *MetricsCollector) worstCircuitState() CircuitState {
+	var worst CircuitState
+	for _, state := range mc.circuitStates {
+		if worst == "" || circuitSeverity(state) > circuitSeverity(worst) {
+			worst = state
+		}
+	}
+	return worst
+}
+
+// circuitSeverity orders the circuit states from closed to open
+func circuitSeverity(state CircuitState) int {
+	switch state {
+	case CircuitOpen:
+		return 2
+	case CircuitHalfOpen:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IncrementHTTPRetries increments the retried HTTP requests counter.
+//
+// This method should be called each time a failed request to the Langfuse API
+// is attempted again according to the backoff policy.
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) IncrementHTTPRetries() {
+	if mc.parent != nil {
+		mc.parent.IncrementHTTPRetries()
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.HTTPRetries++
+}
+
+// RecordRequestBytes records the size of a request body sent to the Langfuse API.
+//
+// Parameters:
+//   - sent: number of body bytes sent, after compression
+//   - saved: number of bytes saved by compression, zero for uncompressed bodies
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) RecordRequestBytes(sent, saved int64) {
+	if mc.parent != nil {
+		mc.parent.RecordRequestBytes(sent, saved)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics.BytesSent += sent
+	mc.metrics.BytesSaved += saved
+}
+
+// RecordBatchSize records the number of events in a batch sent to the Langfuse API.
+//
+// The value is added to the batch size histogram, which shows whether batches are
+// filled up to the configured batch size or flushed early by the batch timeout.
+//
+// Parameters:
+//   - size: number of events in the batch
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) RecordBatchSize(size int) {
+	if mc.parent != nil {
+		mc.parent.RecordBatchSize(size)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.batchSizeHist.observe(float64(size))
+}
+
+// RecordEventSize records the serialized payload size of an event.
+//
+// The value is added to the event size histogram, useful to pick MaxBatchBytes and
+// to spot unexpectedly large inputs or outputs being traced.
+//
+// Parameters:
+//   - size: serialized size of the event in bytes
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) RecordEventSize(size int) {
+	if mc.parent != nil {
+		mc.parent.RecordEventSize(size)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.eventSizeHist.observe(float64(size))
+}
+
+// RecordQueueLatency records the end-to-end latency of an event.
+//
+// The latency is the time from adding the event until the processor finished sending
+// it, including the time spent waiting in the queue, in a batch and on retries. It is
+// added to the queue latency histogram used by CheckHealth.
+//
+// Parameters:
+//   - latency: time from enqueue until the event was sent
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) RecordQueueLatency(latency time.Duration) {
+	if mc.parent != nil {
+		mc.parent.RecordQueueLatency(latency)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.queueLatencyHist.observe(latency.Seconds())
+	mc.recentQueueLatency.observe(latency.Seconds(), time.Now(), mc.latencyWindow())
+}
+
 // UpdateQueueMetrics updates queue-related metrics with current size and capacity.
 //
 // This method should be called periodically to track queue utilization, which is
@@ -459,16 +834,28 @@ func (mc *MetricsCollector) GetMetrics() Metrics {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
 
-	return *mc.metrics
+	metrics := *mc.metrics
+	metrics.ResponseTimeHistogram = mc.responseTimeHist.snapshot()
+	metrics.BatchSizeHistogram = mc.batchSizeHist.snapshot()
+	metrics.EventSizeHistogram = mc.eventSizeHist.snapshot()
+	metrics.QueueLatencyHistogram = mc.queueLatencyHist.snapshot()
+	return metrics
 }
 
 // CheckHealth performs comprehensive health assessment and returns detailed health status.
 //
 // This method evaluates the health of various components and subsystems:
-//   - Queue Health: Based on queue utilization (>90% critical, >70% warning)
+//   - Queue Health: Based on queue utilization (>90% critical, >70% warning by default)
 //   - Processor Health: Based on active processor count (0 is critical)
-//   - API Health: Based on HTTP request error rates (>10% critical, >5% warning)
+//   - API Health: Based on HTTP request error rates (>10% critical, >5% warning by default)
+//     and p99 response time (>5s critical, >2s warning by default)
+//   - Queue Latency: Warning when the p99 time from enqueue until sent is above 30s by default
+//
+// The thresholds can be changed with SetHealthThresholds. The p99 latencies are those of the last 5 to 10 minutes by default.
 //   - Recent Errors: Warnings for errors within the last 5 minutes
+//   - Dropped Events: Warnings for events dropped due to queue overflow within the last 5 minutes
+//   - Spooled Events: Warnings while undelivered events are waiting in the spool
+//   - Circuit Breaker: Critical while open, warning while half-open (reported in Components)
 //
 // Health Status Levels:
 //   - "healthy": All components operating normally
@@ -495,6 +882,13 @@ func (mc *MetricsCollector) GetMetrics() Metrics {
 //	    }
 //	}
 func (mc *MetricsCollector) CheckHealth() HealthStatus {
+	health := mc.assessHealth()
+	mc.storeHealthStatus(health)
+	return health
+}
+
+// assessHealth computes the health status from the metrics, see CheckHealth, without caching it
+func (mc *MetricsCollector) assessHealth() HealthStatus {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
@@ -511,13 +905,13 @@ func (mc *MetricsCollector) CheckHealth() HealthStatus {
 	queueUtilization := float64(mc.metrics.QueueSize) / float64(mc.metrics.QueueCapacity)
 
 	switch {
-	case queueUtilization > queueUtilizationCritical:
+	case queueUtilization > mc.thresholds.QueueUtilizationCritical:
 		health.QueueHealth = cmpHealthCritical
-		health.Errors = append(health.Errors, "Queue utilization critical (>90%)")
+		health.Errors = append(health.Errors, fmt.Sprintf("Queue utilization critical (>%s)", percent(mc.thresholds.QueueUtilizationCritical)))
 		health.Status = healthStatusUnhealthy
-	case queueUtilization > queueUtilizationWarning:
+	case queueUtilization > mc.thresholds.QueueUtilizationWarning:
 		health.QueueHealth = cmpHealthWarning
-		health.Warnings = append(health.Warnings, "Queue utilization high (>70%)")
+		health.Warnings = append(health.Warnings, fmt.Sprintf("Queue utilization high (>%s)", percent(mc.thresholds.QueueUtilizationWarning)))
 		if health.Status == healthStatusHealthy {
 			health.Status = healthStatusDegraded
 		}
@@ -538,35 +932,132 @@ func (mc *MetricsCollector) CheckHealth() HealthStatus {
 	if mc.metrics.HTTPRequestsTotal > 0 {
 		errorRate := float64(mc.metrics.HTTPRequestsFailure) / float64(mc.metrics.HTTPRequestsTotal)
 		switch {
-		case errorRate > errorRateCritical: // 10% error rate
+		case errorRate > mc.thresholds.ErrorRateCritical:
 			health.APIHealth = cmpHealthCritical
-			health.Errors = append(health.Errors, "High API error rate (>10%)")
+			health.Errors = append(health.Errors, fmt.Sprintf("High API error rate (>%s)", percent(mc.thresholds.ErrorRateCritical)))
 			health.Status = healthStatusUnhealthy
-		case errorRate > errorRateWarning: // 5% error rate
+		case errorRate > mc.thresholds.ErrorRateWarning:
 			health.APIHealth = cmpHealthWarning
-			health.Warnings = append(health.Warnings, "Elevated API error rate (>5%)")
+			health.Warnings = append(health.Warnings, fmt.Sprintf("Elevated API error rate (>%s)", percent(mc.thresholds.ErrorRateWarning)))
 			if health.Status == healthStatusHealthy {
 				health.Status = healthStatusDegraded
 			}
 		default:
 			health.APIHealth = cmpHealthHealthy
 		}
+		mc.checkResponseTime(&health)
 	} else {
 		health.APIHealth = cmpHealthUnknown
 	}
 
+	// Check end-to-end queue latency
+	queueLatencyP99 := time.Duration(mc.recentQueueLatency.snapshot(now, mc.latencyWindow()).P99 * float64(time.Second))
+	if queueLatencyP99 > mc.thresholds.QueueLatencyP99Warning {
+		health.Warnings = append(health.Warnings, fmt.Sprintf("High queue latency (p99 >%s)", mc.thresholds.QueueLatencyP99Warning))
+		if health.Status == healthStatusHealthy {
+			health.Status = healthStatusDegraded
+		}
+	}
+
 	// Check for recent errors
-	if mc.metrics.LastErrorAt != nil && now.Sub(*mc.metrics.LastErrorAt) < 5*time.Minute {
+	if mc.metrics.LastErrorAt != nil && now.Sub(*mc.metrics.LastErrorAt) < recentIssueWindow {
 		health.Warnings = append(health.Warnings, "Recent errors detected")
 		if health.Status == healthStatusHealthy {
 			health.Status = healthStatusDegraded
 		}
 	}
 
-	mc.healthStatus = &health
+	// Check for recently dropped events
+	if mc.metrics.LastEventDroppedAt != nil && now.Sub(*mc.metrics.LastEventDroppedAt) < recentIssueWindow {
+		health.Warnings = append(health.Warnings, "Events dropped due to queue overflow")
+		if health.Status == healthStatusHealthy {
+			health.Status = healthStatusDegraded
+		}
+	}
+
+	// Check the circuit breaker
+	switch mc.metrics.CircuitBreakerState {
+	case CircuitOpen:
+		health.Components = map[string]ComponentHealthValue{circuitBreakerComponent: cmpHealthCritical}
+		health.Errors = append(health.Errors, "Circuit breaker open, requests to the API are paused")
+		health.Status = healthStatusUnhealthy
+	case CircuitHalfOpen:
+		health.Components = map[string]ComponentHealthValue{circuitBreakerComponent: cmpHealthWarning}
+		health.Warnings = append(health.Warnings, "Circuit breaker half-open, probing the API")
+		if health.Status == healthStatusHealthy {
+			health.Status = healthStatusDegraded
+		}
+	case CircuitClosed:
+		health.Components = map[string]ComponentHealthValue{circuitBreakerComponent: cmpHealthHealthy}
+	}
+
+	// Check for events waiting in the spool
+	if mc.metrics.SpoolDepth > 0 {
+		health.Warnings = append(health.Warnings, "Undelivered events waiting in spool")
+		if health.Status == healthStatusHealthy {
+			health.Status = healthStatusDegraded
+		}
+	}
+
 	return health
 }
 
+// checkResponseTime downgrades the API health when the p99 response time is above the thresholds,
+// must be called with the lock held
+func (mc *MetricsCollector) checkResponseTime(health *HealthStatus) {
+	responseTimeP99 := time.Duration(mc.recentResponseTimes.snapshot(time.Now(), mc.latencyWindow()).P99 * float64(time.Second))
+	switch {
+	case responseTimeP99 > mc.thresholds.ResponseTimeP99Critical:
+		health.APIHealth = cmpHealthCritical
+		health.Errors = append(health.Errors, fmt.Sprintf("High API response time (p99 >%s)", mc.thresholds.ResponseTimeP99Critical))
+		health.Status = healthStatusUnhealthy
+	case responseTimeP99 > mc.thresholds.ResponseTimeP99Warning:
+		if health.APIHealth == cmpHealthHealthy {
+			health.APIHealth = cmpHealthWarning
+		}
+		health.Warnings = append(health.Warnings, fmt.Sprintf("Elevated API response time (p99 >%s)", mc.thresholds.ResponseTimeP99Warning))
+		if health.Status == healthStatusHealthy {
+			health.Status = healthStatusDegraded
+		}
+	}
+}
+
+// latencyWindow returns the period the p99 thresholds are evaluated on, must be called with the lock held
+func (mc *MetricsCollector) latencyWindow() time.Duration {
+	if mc.thresholds.LatencyWindow > 0 {
+		return mc.thresholds.LatencyWindow
+	}
+	return recentIssueWindow
+}
+
+// SetHealthThresholds replaces the thresholds used by CheckHealth.
+//
+// The client sets the thresholds from the configuration, see NewHealthThresholds.
+// The default thresholds are returned by DefaultHealthThresholds.
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) SetHealthThresholds(thresholds HealthThresholds) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.thresholds = thresholds
+}
+
+// storeHealthStatus replaces the cached health status returned by GetHealthStatus.
+//
+// Used to cache health statuses that include checks performed outside of the collector.
+// A copy is cached so that the caller may keep using the status.
+//
+// Thread-safe for concurrent access.
+func (mc *MetricsCollector) storeHealthStatus(health HealthStatus) {
+	cached := health.clone()
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.healthStatus = &cached
+}
+
 // GetHealthStatus returns the most recently computed health status.
 //
 // This method returns the cached health status from the last call to CheckHealth().
@@ -595,7 +1086,7 @@ func (mc *MetricsCollector) GetHealthStatus() HealthStatus {
 	if mc.healthStatus == nil {
 		return HealthStatus{Status: healthStatusUnknown}
 	}
-	return *mc.healthStatus
+	return mc.healthStatus.clone()
 }
 
 // Reset resets all metrics and health status to initial values.
@@ -624,8 +1115,9 @@ func (mc *MetricsCollector) Reset() {
 
 	now := time.Now().UTC()
 	mc.metrics = &Metrics{
-		StartTime:       now,
-		MinResponseTime: time.Hour,
+		StartTime:           now,
+		MinResponseTime:     time.Hour,
+		CircuitBreakerState: mc.worstCircuitState(),
 	}
 	mc.healthStatus = &HealthStatus{
 		Status:          "starting",
@@ -633,4 +1125,10 @@ func (mc *MetricsCollector) Reset() {
 	}
 	mc.startTime = now
 	mc.responseTimes = make([]time.Duration, 0, mc.maxResponseTimes)
+	mc.responseTimeHist = newHistogram(responseTimeBuckets)
+	mc.batchSizeHist = newHistogram(batchSizeBuckets)
+	mc.eventSizeHist = newHistogram(eventSizeBuckets)
+	mc.queueLatencyHist = newHistogram(queueLatencyBuckets)
+	mc.recentResponseTimes = newWindowedHistogram(responseTimeBuckets, now)
+	mc.recentQueueLatency = newWindowedHistogram(queueLatencyBuckets, now)
 }
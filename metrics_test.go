@@ -0,0 +1,83 @@
+package langfuse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+)
+
+func Test_CheckHealth_UsesResponseTimePercentiles(t *testing.T) {
+	testCases := []struct {
+		name              string
+		responseTime      time.Duration
+		expectedAPIHealth langfuse.ComponentHealthValue
+		expectedStatus    langfuse.HealthStatusValue
+	}{
+		{name: "fast responses", responseTime: time.Millisecond * 40, expectedAPIHealth: "healthy", expectedStatus: "healthy"},
+		{name: "slow responses", responseTime: time.Second * 3, expectedAPIHealth: "warning", expectedStatus: "degraded"},
+		{name: "very slow responses", responseTime: time.Second * 8, expectedAPIHealth: "critical", expectedStatus: "unhealthy"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			collector := langfuse.NewMetricsCollector()
+			collector.UpdateActiveProcessors(1)
+			collector.UpdateQueueMetrics(0, 10)
+			for range 100 {
+				collector.RecordHTTPRequest(true, test.responseTime)
+			}
+
+			health := collector.CheckHealth()
+
+			assert.Equal(t, test.expectedAPIHealth, health.APIHealth)
+			assert.Equal(t, test.expectedStatus, health.Status)
+		})
+	}
+}
+
+func Test_CheckHealth_UsesConfiguredThresholds(t *testing.T) {
+	cfg := testConfig()
+	cfg.HealthQueueUtilizationWarning = 0.2
+	cfg.HealthQueueUtilizationCritical = 0.5
+
+	collector := langfuse.NewMetricsCollector()
+	collector.SetHealthThresholds(langfuse.NewHealthThresholds(cfg))
+	collector.UpdateActiveProcessors(1)
+	collector.UpdateQueueMetrics(3, 10)
+
+	health := collector.CheckHealth()
+
+	assert.Equal(t, langfuse.ComponentHealthValue("warning"), health.QueueHealth)
+	assert.Equal(t, []string{"Queue utilization high (>20%)"}, health.Warnings)
+	assert.InDelta(t, 0.05, langfuse.NewHealthThresholds(cfg).ErrorRateWarning, 0)
+}
+
+func Test_CheckHealth_EvaluatesLatencyOfRecentWindow(t *testing.T) {
+	thresholds := langfuse.DefaultHealthThresholds()
+	thresholds.LatencyWindow = time.Millisecond * 50
+	collector := langfuse.NewMetricsCollector()
+	collector.SetHealthThresholds(thresholds)
+	collector.UpdateActiveProcessors(1)
+	collector.UpdateQueueMetrics(0, 10)
+	for range 100 {
+		collector.RecordHTTPRequest(true, time.Second*8)
+		collector.RecordQueueLatency(time.Minute)
+	}
+
+	health := collector.CheckHealth()
+	assert.Equal(t, langfuse.ComponentHealthValue("critical"), health.APIHealth)
+	assert.Contains(t, health.Warnings, "High queue latency (p99 >30s)")
+
+	// Once two windows elapsed only the latencies recorded since count
+	time.Sleep(thresholds.LatencyWindow * 2)
+	collector.RecordHTTPRequest(true, time.Millisecond*40)
+	collector.RecordQueueLatency(time.Millisecond * 40)
+
+	health = collector.CheckHealth()
+	assert.Equal(t, langfuse.ComponentHealthValue("healthy"), health.APIHealth)
+	assert.Equal(t, langfuse.HealthStatusValue("healthy"), health.Status)
+	assert.Greater(t, collector.GetMetrics().ResponseTimeHistogram.P99, 5.0, "the metrics keep the lifetime histogram")
+}
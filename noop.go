@@ -0,0 +1,103 @@
+package langfuse
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// noopService discards every event, used when Langfuse is disabled
+type noopService struct {
+	*serviceCore
+	stateMu sync.RWMutex
+	stopped bool
+}
+
+// NewNoop initialise new Langfuse instance discarding every event, for environments where Langfuse is disabled.
+// New and NewWithClient return it as well when Enabled is set to false in the config.
+// Events still get an ID and tracing handles work as usual, so code does not need to branch.
+func NewNoop() Langfuse {
+	return &noopService{serviceCore: newOfflineCore(nil)}
+}
+
+func (n *noopService) Add(event types.LangfuseEvent) *uuid.UUID {
+	return n.AddEvent(context.Background(), event)
+}
+
+// AddEvent discards the event and returns its unique ID, generating one if missing, nil for update events without an ID
+func (n *noopService) AddEvent(_ context.Context, event types.LangfuseEvent) *uuid.UUID {
+	if err := ensureEventID(event); err != nil {
+		return nil
+	}
+	return event.GetID()
+}
+
+// AddEventWithResult discards the event and returns its Delivery, which is already complete.
+// Its error is ErrInvalidEventID for update events without an ID, nil otherwise.
+func (n *noopService) AddEventWithResult(_ context.Context, event types.LangfuseEvent) *Delivery {
+	err := ensureEventID(event)
+	delivery := newDelivery(eventID(event))
+	delivery.complete(newDeliveryResult(event, err, 0))
+	return delivery
+}
+
+// StartTrace starts a new trace and returns a context carrying it, the trace is discarded when the handle ends
+func (n *noopService) StartTrace(ctx context.Context, name string) (context.Context, *TraceHandle) {
+	return startTrace(ctx, n, name)
+}
+
+// StartSpan starts a span linked to the trace and observation in the context
+func (n *noopService) StartSpan(ctx context.Context, name string) (context.Context, *SpanHandle) {
+	return startSpan(ctx, n, name)
+}
+
+// StartGeneration starts a generation linked to the trace and observation in the context
+func (n *noopService) StartGeneration(ctx context.Context, name string) (context.Context, *GenerationHandle) {
+	return startGeneration(ctx, n, name)
+}
+
+// Flush returns immediately as there is nothing to send, ErrServiceStopped after Stop
+func (n *noopService) Flush(_ context.Context) error {
+	n.stateMu.RLock()
+	defer n.stateMu.RUnlock()
+
+	if n.stopped {
+		return ErrServiceStopped
+	}
+	return nil
+}
+
+// Stop marks the service stopped, returns ErrServiceStopped when it already is
+func (n *noopService) Stop(_ context.Context) error {
+	n.stateMu.Lock()
+	defer n.stateMu.Unlock()
+
+	if n.stopped {
+		return ErrServiceStopped
+	}
+	n.stopped = true
+	n.metricsCollector.UpdateActiveProcessors(0)
+	return nil
+}
+
+// RegisterProject accepts any project, its events are discarded like all others
+func (n *noopService) RegisterProject(_ string, _ *config.Langfuse) error {
+	return nil
+}
+
+// Projects returns the default project only
+func (n *noopService) Projects() []string {
+	return []string{DefaultProject}
+}
+
+// ProjectMetrics returns the metrics of the service for the default project
+func (n *noopService) ProjectMetrics(name string) (Metrics, bool) {
+	if name != DefaultProject {
+		return Metrics{}, false
+	}
+	return n.GetMetrics(), true
+}
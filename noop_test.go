@@ -0,0 +1,57 @@
+package langfuse_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/mock"
+)
+
+func Test_New_WhenDisabled_ReturnsNoopWithoutValidatingConfig(t *testing.T) {
+	enabled := false
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		require.Fail(t, "disabled langfuse must not send requests")
+		return nil, nil
+	})}
+	subject := langfuse.NewWithClient(&config.Langfuse{Enabled: &enabled}, httpClient)
+
+	id := subject.Add(traceWithID("b0000000-0000-0000-0000-000000000001"))
+	_, trace := subject.StartTrace(context.TODO(), "agent-run")
+	trace.End()
+	result, err := subject.AddEventWithResult(context.TODO(), traceWithID("b0000000-0000-0000-0000-000000000002")).Wait(context.TODO())
+
+	assert.Equal(t, "b0000000-0000-0000-0000-000000000001", id.String())
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+	require.NoError(t, subject.Flush(context.TODO()))
+	assert.Equal(t, langfuse.HealthStatusValue("healthy"), subject.CheckHealth(context.TODO()).Status)
+	require.NoError(t, subject.Stop(context.TODO()))
+	assert.ErrorIs(t, subject.Stop(context.TODO()), langfuse.ErrServiceStopped)
+}
+
+func Test_Config_IsEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	testCases := []struct {
+		name     string
+		enabled  *bool
+		expected bool
+	}{
+		{name: "not set", enabled: nil, expected: true},
+		{name: "enabled", enabled: &enabled, expected: true},
+		{name: "disabled", enabled: &disabled, expected: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Langfuse{Enabled: test.enabled}
+
+			assert.Equal(t, test.expected, cfg.IsEnabled())
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package langfuse
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/logger"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// DefaultProject the name of the project configured by the config given to the constructor,
+// events are sent to it unless they are routed to another project
+const DefaultProject = "default"
+
+// ProjectRouter returns the name of the project the event is sent to, an empty name selects the default project
+type ProjectRouter func(ctx context.Context, event types.LangfuseEvent) string
+
+// MultiProjectLangfuse a Langfuse instance sending events to several Langfuse projects, e.g. one per tenant of a gateway.
+// The projects share the queue and the event processors, each has its own credentials, batching and metrics.
+//
+// An event is sent to the project named in the context given to AddEvent, see ContextWithProject, otherwise to the
+// project returned by the router given with WithProjectRouter, otherwise to the default project.
+// Events routed to a project which is not registered are rejected with ErrUnknownProject.
+type MultiProjectLangfuse interface {
+	Langfuse
+	// RegisterProject adds a project events can be routed to. Of its config the URL, the credentials, the batching
+	// (BatchSize, BatchTimeout, MaxBatchBytes) and the HTTP settings (timeouts, retries, circuit breaker, compression)
+	// are used, the queue, processors and spool are configured by the default project. Batches are flushed on the
+	// ticks of the default project's BatchTimeout, so a project's BatchTimeout is rounded to a multiple of it.
+	// Returns an *Error with code INVALID_CONFIG when the config is invalid or the name is already registered.
+	RegisterProject(name string, config *config.Langfuse) error
+	// Projects returns the names of the registered projects, including DefaultProject, in alphabetical order
+	Projects() []string
+	// ProjectMetrics returns the metrics of the events sent to the project, false when it is not registered.
+	// GetMetrics returns the totals of all projects.
+	ProjectMetrics(name string) (Metrics, bool)
+}
+
+// projectContextKey the context key of the project name, see ContextWithProject
+type projectContextKey struct{}
+
+// ContextWithProject returns a copy of ctx routing the events added with it to the named project,
+// taking precedence over the router given with WithProjectRouter
+func ContextWithProject(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, projectContextKey{}, name)
+}
+
+// ProjectFromContext returns the name of the project the events added with ctx are routed to, if any
+func ProjectFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(projectContextKey{}).(string)
+	return name, ok
+}
+
+// WithProjectRouter routes the events without a project in their context with the router, see MultiProjectLangfuse
+func WithProjectRouter(router ProjectRouter) Option {
+	return func(c *serviceCore) {
+		c.router = router
+	}
+}
+
+// project a Langfuse project the service sends events to
+type project struct {
+	name    string
+	config  *config.Langfuse
+	client  Client
+	metrics *MetricsCollector
+}
+
+// newProject creates the project with its own client, recording its metrics in the metrics of the service as well
+func newProject(name string, config *config.Langfuse, customHTTPClient *http.Client, serviceMetrics *MetricsCollector) *project {
+	metrics := newProjectMetricsCollector(serviceMetrics)
+	return &project{
+		name:    name,
+		config:  config,
+		client:  NewClient(config, customHTTPClient, WithClientMetrics(metrics)),
+		metrics: metrics,
+	}
+}
+
+// projectRegistry the projects of a service by name
+type projectRegistry struct {
+	mutex      sync.RWMutex
+	projects   map[string]*project
+	httpClient *http.Client
+}
+
+// NewMultiProject initialise new Langfuse instance sending events to the default project configured by config and to
+// the projects added with RegisterProject, see MultiProjectLangfuse.
+// The process exits when the config is invalid, use NewMultiProjectE to handle the error instead.
+func NewMultiProject(config *config.Langfuse, customHTTPClient *http.Client, opts ...Option) MultiProjectLangfuse {
+	service, err := NewMultiProjectE(config, customHTTPClient, opts...)
+	if err != nil {
+		logger := logger.FromContext(context.Background())
+		logger.Fatalf("invalid langfuse configuration: %v", err)
+	}
+	return service
+}
+
+// NewMultiProjectE initialise new Langfuse instance like NewMultiProject, returning an error when the config is invalid.
+// The error is an *Error with code INVALID_CONFIG, detailing the invalid field and the reason.
+// SyncMode is not supported, a no-op instance is returned when Langfuse is disabled in the config.
+func NewMultiProjectE(config *config.Langfuse, customHTTPClient *http.Client, opts ...Option) (MultiProjectLangfuse, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !config.IsEnabled():
+		return &noopService{serviceCore: newOfflineCore(nil)}, nil
+	case config.SyncMode:
+		return nil, NewConfigError("SyncMode", "is not supported with multiple projects")
+	default:
+		return newLangfuseService(config, customHTTPClient, opts), nil
+	}
+}
+
+// RegisterProject adds a project events can be routed to
+func (l *langfuseService) RegisterProject(name string, config *config.Langfuse) error {
+	if name == "" {
+		return NewConfigError("name", "must not be empty")
+	}
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+	if !config.IsEnabled() {
+		return NewConfigError("Enabled", "must not be false for a registered project")
+	}
+
+	l.registry.mutex.Lock()
+	defer l.registry.mutex.Unlock()
+
+	if _, found := l.registry.projects[name]; found {
+		return NewConfigError("name", "project "+name+" is already registered")
+	}
+	l.registry.projects[name] = newProject(name, config, l.registry.httpClient, l.metricsCollector)
+	return nil
+}
+
+// Projects returns the names of the registered projects in alphabetical order
+func (l *langfuseService) Projects() []string {
+	l.registry.mutex.RLock()
+	defer l.registry.mutex.RUnlock()
+	return slices.Sorted(maps.Keys(l.registry.projects))
+}
+
+// ProjectMetrics returns the metrics of the events sent to the project, false when it is not registered
+func (l *langfuseService) ProjectMetrics(name string) (Metrics, bool) {
+	l.registry.mutex.RLock()
+	project, found := l.registry.projects[name]
+	l.registry.mutex.RUnlock()
+
+	if !found {
+		return Metrics{}, false
+	}
+	return project.metrics.GetMetrics(), true
+}
+
+// route returns the project the event is sent to, an error when it is not registered
+func (l *langfuseService) route(ctx context.Context, event types.LangfuseEvent) (*project, error) {
+	name, found := ProjectFromContext(ctx)
+	if !found && l.router != nil {
+		name = l.router(ctx, event)
+	}
+	if name == "" {
+		name = DefaultProject
+	}
+
+	l.registry.mutex.RLock()
+	project, registered := l.registry.projects[name]
+	l.registry.mutex.RUnlock()
+
+	if !registered {
+		return nil, ErrUnknownProject.WithDetails(map[string]any{"project": name})
+	}
+	return project, nil
+}
+
+// projectBatch the events of a project collected by a batch processor
+type projectBatch struct {
+	project *project
+	items   []eventChanItem
+	bytes   int
+	// held is set while the circuit breaker of the project holds back the batch
+	held bool
+	// waiting the events read from the queue while the batch is held back, added to the batch once it was sent
+	waiting []eventChanItem
+	// ticks the number of batch timeout ticks since the batch was last flushed on timeout
+	ticks int
+}
+
+// newProjectBatch creates an empty batch of the project
+func newProjectBatch(project *project) *projectBatch {
+	return &projectBatch{project: project, bytes: batchEnvelopeBytes}
+}
+
+// ticksPerFlush returns after how many ticks of the processor the batch is flushed on timeout.
+// The batch timeout of the project is rounded to a multiple of the tick interval, but at least one tick.
+func (b *projectBatch) ticksPerFlush(tickInterval time.Duration) int {
+	if tickInterval <= 0 || b.project.config.BatchTimeout <= tickInterval {
+		return 1
+	}
+	return int((b.project.config.BatchTimeout + tickInterval/2) / tickInterval)
+}
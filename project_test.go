@@ -0,0 +1,195 @@
+package langfuse_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/mock"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_MultiProject_RoutesEventsToProjects(t *testing.T) {
+	testCases := []struct {
+		name            string
+		ctx             context.Context
+		event           *types.TraceEvent
+		expectedProject string
+		expectedKey     string
+	}{
+		{
+			name:            "event without route is sent to the default project",
+			ctx:             context.Background(),
+			event:           &types.TraceEvent{Name: "LLM"},
+			expectedProject: langfuse.DefaultProject,
+			expectedKey:     "LangfusePublicKey",
+		},
+		{
+			name:            "project in the context",
+			ctx:             langfuse.ContextWithProject(context.Background(), "tenant-a"),
+			event:           &types.TraceEvent{Name: "LLM"},
+			expectedProject: "tenant-a",
+			expectedKey:     "pk-tenant-a",
+		},
+		{
+			name:            "project returned by the router",
+			ctx:             context.Background(),
+			event:           &types.TraceEvent{Name: "LLM", UserID: "tenant-b"},
+			expectedProject: "tenant-b",
+			expectedKey:     "pk-tenant-b",
+		},
+		{
+			name:            "project in the context takes precedence over the router",
+			ctx:             langfuse.ContextWithProject(context.Background(), "tenant-a"),
+			event:           &types.TraceEvent{Name: "LLM", UserID: "tenant-b"},
+			expectedProject: "tenant-a",
+			expectedKey:     "pk-tenant-a",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var publicKeys []string
+			httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				publicKey, _, _ := request.BasicAuth()
+				mutex.Lock()
+				publicKeys = append(publicKeys, publicKey)
+				mutex.Unlock()
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+			})}
+			router := func(_ context.Context, event types.LangfuseEvent) string {
+				if trace, ok := event.(*types.TraceEvent); ok {
+					return trace.UserID
+				}
+				return ""
+			}
+			subject, err := langfuse.NewMultiProjectE(testConfig(), httpClient, langfuse.WithProjectRouter(router))
+			require.NoError(t, err)
+			require.NoError(t, subject.RegisterProject("tenant-a", projectConfig("pk-tenant-a")))
+			require.NoError(t, subject.RegisterProject("tenant-b", projectConfig("pk-tenant-b")))
+
+			delivery := subject.AddEventWithResult(test.ctx, test.event)
+			result, err := delivery.Wait(context.TODO())
+			require.NoError(t, err)
+			require.NoError(t, result.Err)
+			require.NoError(t, subject.Stop(context.TODO()))
+
+			mutex.Lock()
+			assert.Equal(t, []string{test.expectedKey}, publicKeys)
+			mutex.Unlock()
+			assert.Equal(t, []string{"default", "tenant-a", "tenant-b"}, subject.Projects())
+			for _, name := range subject.Projects() {
+				metrics, found := subject.ProjectMetrics(name)
+				require.True(t, found)
+				expectedProcessed := int64(0)
+				if name == test.expectedProject {
+					expectedProcessed = 1
+				}
+				assert.Equal(t, expectedProcessed, metrics.EventsProcessed, name)
+			}
+			assert.Equal(t, int64(1), subject.GetMetrics().EventsProcessed)
+			assert.Equal(t, int64(1), subject.GetMetrics().HTTPRequestsSuccess)
+		})
+	}
+}
+
+func Test_MultiProject_BatchesEventsPerProject(t *testing.T) {
+	var mutex sync.Mutex
+	var batchSizes []string
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(request.Body)
+		publicKey, _, _ := request.BasicAuth()
+		mutex.Lock()
+		batchSizes = append(batchSizes, fmt.Sprintf("%s:%d", publicKey, strings.Count(string(body), `"type":"trace-create"`)))
+		mutex.Unlock()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+	cfg := testConfig()
+	cfg.BatchTimeout = time.Hour
+	subject := langfuse.NewMultiProject(cfg, httpClient)
+	tenantConfig := projectConfig("pk-tenant-a")
+	tenantConfig.BatchSize = 2
+	require.NoError(t, subject.RegisterProject("tenant-a", tenantConfig))
+	tenantCtx := langfuse.ContextWithProject(context.Background(), "tenant-a")
+
+	subject.AddEvent(context.Background(), &types.TraceEvent{Name: "LLM"})
+	subject.AddEvent(tenantCtx, &types.TraceEvent{Name: "LLM"})
+	subject.AddEvent(tenantCtx, &types.TraceEvent{Name: "LLM"})
+
+	// The batch of the tenant is full and sent, the one of the default project waits for Stop
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(batchSizes) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{"pk-tenant-a:2", "LangfusePublicKey:1"}, batchSizes)
+}
+
+func Test_MultiProject_WhenProjectUnknown_RejectsEvent(t *testing.T) {
+	subject := langfuse.NewMultiProject(testConfig(), &http.Client{Transport: mock.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		t.Error("no request expected for an unknown project")
+		return nil, nil
+	})})
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	delivery := subject.AddEventWithResult(langfuse.ContextWithProject(context.Background(), "unknown"), &types.TraceEvent{Name: "LLM"})
+
+	result, err := delivery.Wait(context.TODO())
+	require.NoError(t, err)
+	var langfuseErr *langfuse.Error
+	require.ErrorAs(t, result.Err, &langfuseErr)
+	assert.Equal(t, langfuse.ErrUnknownProject.Code, langfuseErr.Code)
+	assert.Equal(t, "unknown", langfuseErr.Details["project"])
+	assert.Equal(t, int64(1), subject.GetMetrics().EventsRejected)
+}
+
+func Test_MultiProject_RegisterProject_WhenInvalid_ReturnsConfigError(t *testing.T) {
+	disabled := false
+	testCases := []struct {
+		name          string
+		projectName   string
+		config        *config.Langfuse
+		expectedField string
+	}{
+		{name: "empty name", projectName: "", config: projectConfig("pk"), expectedField: "name"},
+		{name: "already registered", projectName: langfuse.DefaultProject, config: projectConfig("pk"), expectedField: "name"},
+		{name: "missing URL", projectName: "tenant-a", config: &config.Langfuse{PublicKey: "pk", SecretKey: "sk", NumberOfEventProcessor: 1, BatchSize: 1}, expectedField: "URL"},
+		{name: "disabled", projectName: "tenant-a", config: &config.Langfuse{Enabled: &disabled}, expectedField: "Enabled"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			subject := langfuse.NewMultiProject(testConfig(), &http.Client{})
+			defer func() { _ = subject.Stop(context.TODO()) }()
+
+			err := subject.RegisterProject(test.projectName, test.config)
+
+			var langfuseErr *langfuse.Error
+			require.ErrorAs(t, err, &langfuseErr)
+			assert.Equal(t, langfuse.ErrInvalidConfig.Code, langfuseErr.Code)
+			assert.Equal(t, test.expectedField, langfuseErr.Details["field"])
+			assert.Equal(t, []string{langfuse.DefaultProject}, subject.Projects())
+		})
+	}
+}
+
+func projectConfig(publicKey string) *config.Langfuse {
+	cfg := testConfig()
+	cfg.PublicKey = publicKey
+	return cfg
+}
@@ -0,0 +1,151 @@
+package langfuse
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPrometheusNamespace = "langfuse"
+	prometheusContentType      = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// MetricsProvider provides a snapshot of the client metrics, implemented by Langfuse and MetricsCollector
+type MetricsProvider interface {
+	GetMetrics() Metrics
+}
+
+// PrometheusOptions configures the metric names and labels exported by NewPrometheusHandler
+type PrometheusOptions struct {
+	// Namespace is prepended to every metric name, defaults to "langfuse"
+	Namespace string
+	// ConstLabels are added to every exported sample, e.g. the service name
+	ConstLabels map[string]string
+}
+
+// NewPrometheusHandler returns an http.Handler exporting the metrics of the provider in the Prometheus text
+// exposition format, so it can be scraped without depending on the Prometheus client library.
+//
+// Example:
+//
+//	mux.Handle("/metrics", langfuse.NewPrometheusHandler(client, langfuse.PrometheusOptions{
+//	    ConstLabels: map[string]string{"service": "checkout"},
+//	}))
+func NewPrometheusHandler(provider MetricsProvider, opts PrometheusOptions) http.Handler {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = defaultPrometheusNamespace
+	}
+	writer := &prometheusWriter{namespace: namespace, constLabels: formatConstLabels(opts.ConstLabels)}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var buffer bytes.Buffer
+		writer.write(&buffer, provider.GetMetrics())
+		w.Header().Set("Content-Type", prometheusContentType)
+		_, _ = w.Write(buffer.Bytes())
+	})
+}
+
+// prometheusWriter writes metrics in the Prometheus text exposition format
+type prometheusWriter struct {
+	namespace   string
+	constLabels []string
+}
+
+// write writes all exported metrics
+func (p *prometheusWriter) write(buffer *bytes.Buffer, m Metrics) {
+	p.counter(buffer, "events_processed_total", "Total number of events sent to Langfuse.", float64(m.EventsProcessed))
+	p.counter(buffer, "events_queued_total", "Total number of events added to the queue.", float64(m.EventsQueued))
+	p.counter(buffer, "events_failed_total", "Total number of events that could not be sent.", float64(m.EventsFailed))
+	p.counter(buffer, "events_dropped_total", "Total number of events dropped due to queue overflow.", float64(m.EventsDropped))
+	p.counter(buffer, "events_rejected_total", "Total number of events added after the service stopped.", float64(m.EventsRejected))
+	p.counter(buffer, "events_spooled_total", "Total number of undeliverable events written to the spool.", float64(m.EventsSpooled))
+	p.counter(buffer, "events_replayed_total", "Total number of spooled events delivered by the replay.", float64(m.EventsReplayed))
+	p.counter(buffer, "batches_processed_total", "Total number of batches sent to Langfuse.", float64(m.BatchesProcessed))
+	p.counter(buffer, "batches_failed_total", "Total number of batches that could not be sent.", float64(m.BatchesFailed))
+	p.counter(buffer, "http_requests_total", "Total number of HTTP requests made to the Langfuse API.", float64(m.HTTPRequestsTotal))
+	p.counter(buffer, "http_requests_failed_total", "Total number of failed HTTP requests made to the Langfuse API.", float64(m.HTTPRequestsFailure))
+	p.counter(buffer, "http_retries_total", "Total number of retried HTTP requests.", float64(m.HTTPRetries))
+	p.counter(buffer, "http_request_bytes_total", "Total number of request body bytes sent, after compression.", float64(m.BytesSent))
+	p.histogram(buffer, "http_request_duration_seconds", "Duration of HTTP requests made to the Langfuse API.", m.ResponseTimeHistogram)
+	p.histogram(buffer, "batch_size_events", "Number of events in each batch sent to Langfuse.", m.BatchSizeHistogram)
+	p.histogram(buffer, "event_size_bytes", "Serialized size of the events.", m.EventSizeHistogram)
+	p.histogram(buffer, "queue_latency_seconds", "Time from adding an event until it was sent.", m.QueueLatencyHistogram)
+
+	p.gauge(buffer, "queue_size", "Number of events waiting in the queue.", float64(m.QueueSize))
+	p.gauge(buffer, "queue_capacity", "Maximum number of events the queue can hold.", float64(m.QueueCapacity))
+	p.gauge(buffer, "queue_utilization_ratio", "Ratio of the queue capacity in use.", queueUtilization(m))
+	p.gauge(buffer, "active_processors", "Number of active event processors.", float64(m.ActiveProcessors))
+	p.gauge(buffer, "spool_depth", "Number of events waiting in the spool.", float64(m.SpoolDepth))
+	p.gauge(buffer, "spool_bytes", "Size of the spool in bytes.", float64(m.SpoolBytes))
+}
+
+func (p *prometheusWriter) counter(buffer *bytes.Buffer, name, help string, value float64) {
+	p.header(buffer, name, help, "counter")
+	p.sample(buffer, name, nil, value)
+}
+
+func (p *prometheusWriter) gauge(buffer *bytes.Buffer, name, help string, value float64) {
+	p.header(buffer, name, help, "gauge")
+	p.sample(buffer, name, nil, value)
+}
+
+func (p *prometheusWriter) histogram(buffer *bytes.Buffer, name, help string, h Histogram) {
+	p.header(buffer, name, help, "histogram")
+	for _, bucket := range h.Buckets {
+		p.sample(buffer, name+"_bucket", []string{formatLabel("le", formatFloat(bucket.UpperBound))}, float64(bucket.Count))
+	}
+	p.sample(buffer, name+"_bucket", []string{formatLabel("le", "+Inf")}, float64(h.Count))
+	p.sample(buffer, name+"_sum", nil, h.Sum)
+	p.sample(buffer, name+"_count", nil, float64(h.Count))
+}
+
+func (p *prometheusWriter) header(buffer *bytes.Buffer, name, help, metricType string) {
+	fmt.Fprintf(buffer, "# HELP %s_%s %s\n", p.namespace, name, help)
+	fmt.Fprintf(buffer, "# TYPE %s_%s %s\n", p.namespace, name, metricType)
+}
+
+func (p *prometheusWriter) sample(buffer *bytes.Buffer, name string, labels []string, value float64) {
+	buffer.WriteString(p.namespace + "_" + name)
+	if all := append(append([]string(nil), p.constLabels...), labels...); len(all) > 0 {
+		buffer.WriteString("{" + strings.Join(all, ",") + "}")
+	}
+	buffer.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// queueUtilization returns the ratio of the queue capacity in use
+func queueUtilization(m Metrics) float64 {
+	if m.QueueCapacity == 0 {
+		return 0
+	}
+	return float64(m.QueueSize) / float64(m.QueueCapacity)
+}
+
+// formatConstLabels formats the labels sorted by name so that the output is stable
+func formatConstLabels(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	formatted := make([]string, 0, len(names))
+	for _, name := range names {
+		formatted = append(formatted, formatLabel(name, labels[name]))
+	}
+	return formatted
+}
+
+// formatLabel formats a label pair, escaping the value as required by the exposition format
+func formatLabel(name, value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return name + `="` + escaped + `"`
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,75 @@
+package langfuse_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+)
+
+func Test_PrometheusHandler_ExportsMetricsInTextFormat(t *testing.T) {
+	collector := langfuse.NewMetricsCollector()
+	collector.IncrementEventsQueued()
+	collector.IncrementEventsQueued()
+	collector.IncrementEventsProcessed()
+	collector.IncrementEventsFailed(errors.New("failed"))
+	collector.UpdateQueueMetrics(5, 20)
+	collector.RecordHTTPRequest(true, time.Millisecond*30)
+	collector.RecordHTTPRequest(false, time.Second*20)
+
+	testCases := []struct {
+		name     string
+		options  langfuse.PrometheusOptions
+		expected []string
+	}{
+		{
+			name:    "default namespace",
+			options: langfuse.PrometheusOptions{},
+			expected: []string{
+				"# TYPE langfuse_events_processed_total counter\nlangfuse_events_processed_total 1\n",
+				"langfuse_events_queued_total 2\n",
+				"langfuse_events_failed_total 1\n",
+				"langfuse_http_requests_total 2\n",
+				"langfuse_http_requests_failed_total 1\n",
+				"# TYPE langfuse_http_request_duration_seconds histogram\n",
+				"langfuse_http_request_duration_seconds_bucket{le=\"0.025\"} 0\n",
+				"langfuse_http_request_duration_seconds_bucket{le=\"0.05\"} 1\n",
+				"langfuse_http_request_duration_seconds_bucket{le=\"10\"} 1\n",
+				"langfuse_http_request_duration_seconds_bucket{le=\"+Inf\"} 2\n",
+				"langfuse_http_request_duration_seconds_sum 20.03\n",
+				"langfuse_http_request_duration_seconds_count 2\n",
+				"# TYPE langfuse_queue_utilization_ratio gauge\nlangfuse_queue_utilization_ratio 0.25\n",
+			},
+		},
+		{
+			name: "custom namespace and const labels",
+			options: langfuse.PrometheusOptions{
+				Namespace:   "tracing",
+				ConstLabels: map[string]string{"service": "checkout", "env": `prod "eu"`},
+			},
+			expected: []string{
+				"tracing_events_processed_total{env=\"prod \\\"eu\\\"\",service=\"checkout\"} 1\n",
+				"tracing_http_request_duration_seconds_bucket{env=\"prod \\\"eu\\\"\",service=\"checkout\",le=\"+Inf\"} 2\n",
+				"tracing_queue_size{env=\"prod \\\"eu\\\"\",service=\"checkout\"} 5\n",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			langfuse.NewPrometheusHandler(collector, test.options).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+			for _, expected := range test.expected {
+				assert.Contains(t, recorder.Body.String(), expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package langfuse
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// TestingT the subset of testing.TB used by the Recorder assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Recorder a Langfuse implementation storing every added event in memory instead of sending it, for tests.
+// Events are recorded on the caller's goroutine, so they can be queried as soon as they are added.
+//
+// Example:
+//
+//	recorder := langfuse.NewRecorder()
+//	runAgent(ctx, recorder)
+//	recorder.AssertEventTypeCount(t, langfuse.EventTypeGenerationCreate, 2)
+//	spans := recorder.EventsByTraceID(traceID.String())
+type Recorder struct {
+	*serviceCore
+	mutex   sync.RWMutex
+	events  []types.LangfuseEvent
+	stopped bool
+}
+
+// NewRecorder initialise new Recorder, the options configure it like NewWithClient, e.g. WithDeliveryHooks.
+// Recorded events are reported as delivered.
+func NewRecorder(opts ...Option) *Recorder {
+	return &Recorder{serviceCore: newOfflineCore(opts)}
+}
+
+func (r *Recorder) Add(event types.LangfuseEvent) *uuid.UUID {
+	return r.AddEvent(context.Background(), event)
+}
+
+// AddEvent records a copy of the event and returns its unique ID, generating one if missing.
+// Events added after Stop are rejected with ErrServiceStopped and update events without an ID with ErrInvalidEventID,
+// neither is recorded.
+func (r *Recorder) AddEvent(_ context.Context, event types.LangfuseEvent) *uuid.UUID {
+	if err := ensureEventID(event); err != nil {
+		r.metricsCollector.IncrementEventsRejected(err)
+		r.eventDropped(event, err)
+		return nil
+	}
+
+	r.mutex.Lock()
+	stopped := r.stopped
+	if !stopped {
+		r.events = append(r.events, event.Clone())
+	}
+	r.mutex.Unlock()
+
+	if stopped {
+		r.metricsCollector.IncrementEventsRejected(ErrServiceStopped)
+		r.eventDropped(event, ErrServiceStopped)
+		return event.GetID()
+	}
+
+	r.metricsCollector.IncrementEventsQueued()
+	r.eventDelivered(r.metricsCollector, event, 0)
+	return event.GetID()
+}
+
+// AddEventWithResult records the event and returns its Delivery, which is already complete
+func (r *Recorder) AddEventWithResult(ctx context.Context, event types.LangfuseEvent) *Delivery {
+	_ = ensureEventID(event) // AddEvent reports an update event without an ID through the delivery
+	delivery := r.track(event)
+	_ = r.AddEvent(ctx, event)
+	return delivery
+}
+
+// StartTrace starts a new trace and returns a context carrying it, the trace is recorded when the handle ends
+func (r *Recorder) StartTrace(ctx context.Context, name string) (context.Context, *TraceHandle) {
+	return startTrace(ctx, r, name)
+}
+
+// StartSpan starts a span linked to the trace and observation in the context
+func (r *Recorder) StartSpan(ctx context.Context, name string) (context.Context, *SpanHandle) {
+	return startSpan(ctx, r, name)
+}
+
+// StartGeneration starts a generation linked to the trace and observation in the context
+func (r *Recorder) StartGeneration(ctx context.Context, name string) (context.Context, *GenerationHandle) {
+	return startGeneration(ctx, r, name)
+}
+
+// Flush returns immediately as events are recorded when they are added, ErrServiceStopped after Stop
+func (r *Recorder) Flush(_ context.Context) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.stopped {
+		return ErrServiceStopped
+	}
+	return nil
+}
+
+// Stop marks the recorder stopped, the recorded events can still be queried
+func (r *Recorder) Stop(_ context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.stopped {
+		return ErrServiceStopped
+	}
+	r.stopped = true
+	r.metricsCollector.UpdateActiveProcessors(0)
+	return nil
+}
+
+// Events returns the recorded events in the order they were added
+func (r *Recorder) Events() []types.LangfuseEvent {
+	return r.filter(func(types.LangfuseEvent) bool { return true })
+}
+
+// Event returns the recorded event with the given ID, the last one when it was added several times
+func (r *Recorder) Event(id uuid.UUID) (types.LangfuseEvent, bool) {
+	events := r.filter(func(event types.LangfuseEvent) bool { return *event.GetID() == id })
+	if len(events) == 0 {
+		return nil, false
+	}
+	return events[len(events)-1], true
+}
+
+// EventsOfType returns the recorded events of the ingestion event type, e.g. EventTypeSpanCreate
+func (r *Recorder) EventsOfType(eventType string) []types.LangfuseEvent {
+	return r.filter(func(event types.LangfuseEvent) bool { return getEventType(event) == eventType })
+}
+
+// EventsByTraceID returns the recorded events of the trace, including the trace itself
+func (r *Recorder) EventsByTraceID(traceID string) []types.LangfuseEvent {
+	return r.filter(func(event types.LangfuseEvent) bool { return eventTraceID(event) == traceID })
+}
+
+// Reset removes the recorded events
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = nil
+}
+
+// AssertEventCount checks that the expected number of events was recorded
+func (r *Recorder) AssertEventCount(t TestingT, expected int) bool {
+	t.Helper()
+	if actual := len(r.Events()); actual != expected {
+		t.Errorf("expected %d recorded langfuse events, got %d", expected, actual)
+		return false
+	}
+	return true
+}
+
+// AssertEventRecorded checks that an event with the given ID was recorded
+func (r *Recorder) AssertEventRecorded(t TestingT, id uuid.UUID) bool {
+	t.Helper()
+	if _, found := r.Event(id); !found {
+		t.Errorf("expected langfuse event %s to be recorded", id)
+		return false
+	}
+	return true
+}
+
+// AssertEventTypeCount checks that the expected number of events of the ingestion event type was recorded
+func (r *Recorder) AssertEventTypeCount(t TestingT, eventType string, expected int) bool {
+	t.Helper()
+	if actual := len(r.EventsOfType(eventType)); actual != expected {
+		t.Errorf("expected %d recorded langfuse events of type %s, got %d", expected, eventType, actual)
+		return false
+	}
+	return true
+}
+
+// AssertTraceEventCount checks that the expected number of events of the trace was recorded, including the trace itself
+func (r *Recorder) AssertTraceEventCount(t TestingT, traceID string, expected int) bool {
+	t.Helper()
+	if actual := len(r.EventsByTraceID(traceID)); actual != expected {
+		t.Errorf("expected %d recorded langfuse events of trace %s, got %d", expected, traceID, actual)
+		return false
+	}
+	return true
+}
+
+// filter returns the recorded events matching the predicate
+func (r *Recorder) filter(matches func(types.LangfuseEvent) bool) []types.LangfuseEvent {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	events := make([]types.LangfuseEvent, 0, len(r.events))
+	for _, event := range r.events {
+		if matches(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// eventTraceID returns the ID of the trace the event belongs to, empty when it has none
+func eventTraceID(event types.LangfuseEvent) string {
+	var traceID *uuid.UUID
+	switch typed := event.(type) {
+	case *types.TraceEvent:
+		traceID = typed.ID
+	case *types.GenerationEvent:
+		traceID = typed.TraceID
+	case *types.GenerationUpdateEvent:
+		traceID = typed.TraceID
+	case *types.SpanEvent:
+		traceID = typed.TraceID
+	case *types.SpanUpdateEvent:
+		traceID = typed.TraceID
+	case *types.EventEvent:
+		traceID = typed.TraceID
+	case *types.ScoreEvent:
+		if typed.TraceID != nil {
+			return *typed.TraceID
+		}
+	}
+
+	if traceID == nil {
+		return ""
+	}
+	return traceID.String()
+}
@@ -0,0 +1,118 @@
+package langfuse_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_Recorder_RecordsTracedObservations(t *testing.T) {
+	subject := langfuse.NewRecorder()
+
+	ctx, trace := subject.StartTrace(context.TODO(), "agent-run")
+	spanCtx, span := subject.StartSpan(ctx, "plan")
+	_, generation := subject.StartGeneration(spanCtx, "llm-call")
+	generation.End()
+	span.End()
+	trace.End()
+	subject.Add(traceWithID("a0000000-0000-0000-0000-000000000001"))
+
+	subject.AssertEventCount(t, 4)
+	subject.AssertTraceEventCount(t, trace.ID().String(), 3)
+	subject.AssertEventTypeCount(t, langfuse.EventTypeSpanCreate, 1)
+	subject.AssertEventRecorded(t, span.ID())
+
+	generations := subject.EventsOfType(langfuse.EventTypeGenerationCreate)
+	require.Len(t, generations, 1)
+	recorded, ok := generations[0].(*types.GenerationEvent)
+	require.True(t, ok)
+	assert.Equal(t, span.ID(), *recorded.ParentObservationID)
+	assert.Equal(t, int64(4), subject.GetMetrics().EventsProcessed)
+}
+
+func Test_Recorder_QueryByTraceID(t *testing.T) {
+	traceID := uuid.MustParse("a0000000-0000-0000-0000-000000000011")
+	traceIDString := traceID.String()
+	otherTraceID := uuid.New()
+
+	testCases := []struct {
+		name     string
+		event    types.LangfuseEvent
+		expected bool
+	}{
+		{name: "trace", event: &types.TraceEvent{ID: &traceID}, expected: true},
+		{name: "span of the trace", event: &types.SpanEvent{TraceID: &traceID}, expected: true},
+		{name: "event of the trace", event: &types.EventEvent{TraceID: &traceID}, expected: true},
+		{name: "score of the trace", event: &types.ScoreEvent{TraceID: &traceIDString, Name: "score"}, expected: true},
+		{name: "span of another trace", event: &types.SpanEvent{TraceID: &otherTraceID}, expected: false},
+		{name: "generation without trace", event: &types.GenerationEvent{}, expected: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			subject := langfuse.NewRecorder()
+
+			id := subject.Add(test.event)
+
+			events := subject.EventsByTraceID(traceIDString)
+			if !test.expected {
+				assert.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			assert.Equal(t, id, events[0].GetID())
+		})
+	}
+}
+
+func Test_Recorder_Assertions_ReportMismatch(t *testing.T) {
+	subject := langfuse.NewRecorder()
+	subject.Add(traceWithID("a0000000-0000-0000-0000-000000000021"))
+	recorder := &testingRecorder{}
+
+	assert.False(t, subject.AssertEventCount(recorder, 2))
+	assert.False(t, subject.AssertEventRecorded(recorder, uuid.MustParse("a0000000-0000-0000-0000-000000000022")))
+	assert.False(t, subject.AssertEventTypeCount(recorder, langfuse.EventTypeScoreCreate, 1))
+	assert.False(t, subject.AssertTraceEventCount(recorder, "a0000000-0000-0000-0000-000000000021", 2))
+	assert.Equal(t, []string{
+		"expected 2 recorded langfuse events, got 1",
+		"expected langfuse event a0000000-0000-0000-0000-000000000022 to be recorded",
+		"expected 1 recorded langfuse events of type score-create, got 0",
+		"expected 2 recorded langfuse events of trace a0000000-0000-0000-0000-000000000021, got 1",
+	}, recorder.errors)
+}
+
+func Test_Recorder_WhenStopped_RejectsEvents(t *testing.T) {
+	recorder := &hookRecorder{}
+	subject := langfuse.NewRecorder(langfuse.WithDeliveryHooks(recorder.hooks()))
+	subject.Add(traceWithID("a0000000-0000-0000-0000-000000000031"))
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	subject.Add(traceWithID("a0000000-0000-0000-0000-000000000032"))
+
+	subject.AssertEventCount(t, 1)
+	assert.Len(t, recorder.get("delivered"), 1)
+	assert.Len(t, recorder.get("dropped"), 1)
+	assert.ErrorIs(t, subject.Stop(context.TODO()), langfuse.ErrServiceStopped)
+
+	subject.Reset()
+	assert.Empty(t, subject.Events())
+}
+
+// testingRecorder records the failures reported by the Recorder assertions
+type testingRecorder struct {
+	errors []string
+}
+
+func (r *testingRecorder) Helper() {}
+
+func (r *testingRecorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
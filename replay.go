@@ -0,0 +1,185 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/logger"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+const defaultReplayBatchSize = 100
+
+// ReplayOptions configures Replay
+type ReplayOptions struct {
+	// BatchSize is the maximum number of events sent per request, 100 when not set
+	BatchSize int
+	// Rate is the maximum number of events sent per second, 0 does not limit the rate
+	Rate int
+}
+
+// ReplayResult summarises a replay
+type ReplayResult struct {
+	// Read is the number of events read from the files
+	Read int
+	// Sent is the number of events accepted by the client
+	Sent int
+	// Duplicates is the number of events skipped because an event with the same envelope ID was already read
+	Duplicates int
+	// Failed is the number of events the client failed to send
+	Failed int
+	// Unreadable is the number of events or lines that could not be decoded, e.g. events of unknown type or without envelope ID
+	Unreadable int
+}
+
+// Replay reads the events of the files in order and sends them with the client, e.g. a client created with NewClient
+// to load the exports of an air-gapped environment into Langfuse, or a JSONLSink to inspect them in a dry run.
+//
+// Files may hold the batch lines of a FileExporter and the event lines of a JSONLSink or of the spool. Events are sent
+// with the ID and timestamp of their original ingestion envelope and deduplicated by the envelope ID across all files,
+// so overlapping exports are sent once while every update of an observation is kept. Unreadable lines and failed
+// batches are logged, counted and skipped. An error is returned when a file cannot be read or the context is done.
+func Replay(ctx context.Context, client Client, paths []string, opts ReplayOptions) (ReplayResult, error) {
+	log := logger.FromContext(ctx)
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReplayBatchSize
+	}
+
+	r := &replayer{client: client, rate: opts.Rate, seen: make(map[string]struct{})}
+	batch := make([]event, 0, batchSize)
+	for _, path := range paths {
+		lines, err := readLines(path)
+		if err != nil {
+			return r.result, ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+				"path": path,
+			})
+		}
+
+		for number, line := range lines {
+			events, unreadable := r.decode(line)
+			for _, err := range unreadable {
+				log.WithError(err).Warnf("skipping unreadable event on line %d of %s", number+1, path)
+			}
+
+			for _, replayed := range events {
+				batch = append(batch, replayed)
+				if len(batch) == batchSize {
+					if err := r.send(ctx, batch); err != nil {
+						return r.result, err
+					}
+					batch = batch[:0]
+				}
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := r.send(ctx, batch); err != nil {
+			return r.result, err
+		}
+	}
+	return r.result, nil
+}
+
+// replayer the state of a Replay
+type replayer struct {
+	client Client
+	rate   int
+	seen   map[string]struct{}
+	result ReplayResult
+}
+
+// decode decodes the ingestion events of a batch or event line, skipping the ones already read.
+// Returns the errors of the events that could not be decoded, or of the line when it is not JSON.
+func (r *replayer) decode(line []byte) ([]event, []error) {
+	var request struct {
+		Batch []json.RawMessage `json:"batch"`
+	}
+	if err := json.Unmarshal(line, &request); err != nil {
+		r.result.Unreadable++
+		return nil, []error{ErrEventProcessing.WithCause(err)}
+	}
+	if request.Batch == nil {
+		request.Batch = []json.RawMessage{line}
+	}
+
+	var unreadable []error
+	events := make([]event, 0, len(request.Batch))
+	for _, raw := range request.Batch {
+		decoded, err := decodeSpooledEvent(raw)
+		if err == nil && decoded.ID == "" {
+			err = NewValidationError("id", nil, "envelope ID is required for deduplication")
+		}
+		if err != nil {
+			r.result.Unreadable++
+			unreadable = append(unreadable, err)
+			continue
+		}
+
+		r.result.Read++
+		if _, found := r.seen[decoded.ID]; found {
+			r.result.Duplicates++
+			continue
+		}
+		r.seen[decoded.ID] = struct{}{}
+		events = append(events, decoded)
+	}
+	return events, unreadable
+}
+
+// send sends the batch and waits as long as the rate requires, returns an error only when the context is done
+func (r *replayer) send(ctx context.Context, batch []event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	events := make([]types.LangfuseEvent, 0, len(batch))
+	envelopes := make(map[types.LangfuseEvent]event, len(batch))
+	for _, replayed := range batch {
+		events = append(events, replayed.Body)
+		envelopes[replayed.Body] = replayed
+	}
+	err := r.client.SendBatch(withReplayedEnvelopes(ctx, envelopes), events)
+	failed := len(batch)
+	if failures := failedEvents(err); err == nil || len(failures) > 0 {
+		failed = len(failures)
+	}
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Errorf("failed to replay %d of %d events", failed, len(batch))
+	}
+	r.result.Sent += len(batch) - failed
+	r.result.Failed += failed
+
+	if r.rate <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(len(batch)) * time.Second / time.Duration(r.rate)):
+		return nil
+	}
+}
+
+// replayedEnvelopesKey the context key of the original envelopes of replayed events
+type replayedEnvelopesKey struct{}
+
+// withReplayedEnvelopes returns a context in which the events are framed in their original envelopes when sent
+func withReplayedEnvelopes(ctx context.Context, envelopes map[types.LangfuseEvent]event) context.Context {
+	return context.WithValue(ctx, replayedEnvelopesKey{}, envelopes)
+}
+
+// replayedEnvelope returns the original envelope of a replayed event, false when the event is not replayed
+func replayedEnvelope(ctx context.Context, ingestionEvent types.LangfuseEvent) (event, bool) {
+	envelopes, ok := ctx.Value(replayedEnvelopesKey{}).(map[types.LangfuseEvent]event)
+	if !ok {
+		return event{}, false
+	}
+	envelope, found := envelopes[ingestionEvent]
+	if found && envelope.Timestamp.IsZero() {
+		envelope.Timestamp = time.Now()
+	}
+	return envelope, found
+}
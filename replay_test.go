@@ -0,0 +1,133 @@
+package langfuse_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_Replay_DeduplicatesEventsByEnvelopeID(t *testing.T) {
+	dir := t.TempDir()
+	traceID := uuid.New()
+	spanID := uuid.New()
+	endTime := time.Now().UTC()
+
+	exportPath := filepath.Join(dir, "export.jsonl")
+	exporter, err := langfuse.NewFileExporter(exportPath)
+	require.NoError(t, err)
+	require.NoError(t, exporter.SendBatch(context.TODO(), []types.LangfuseEvent{
+		&types.TraceEvent{ID: &traceID, Name: "LLM"},
+		&types.SpanEvent{ID: &spanID, TraceID: &traceID, Name: "retrieval"},
+	}))
+	require.NoError(t, exporter.Close())
+	exported := readFileLines(t, exportPath)
+
+	// The second file overlaps with the export and holds two updates of the span
+	sinkPath := filepath.Join(dir, "sink.jsonl")
+	sink, err := langfuse.NewJSONLFileSink(sinkPath)
+	require.NoError(t, err)
+	require.NoError(t, sink.SendBatch(context.TODO(), []types.LangfuseEvent{
+		&types.SpanUpdateEvent{ID: &spanID, TraceID: &traceID, Output: "partial"},
+		&types.SpanUpdateEvent{ID: &spanID, TraceID: &traceID, EndTime: &endTime},
+	}))
+	require.NoError(t, sink.Close())
+	appendLine(t, sinkPath, exported[0])
+	appendLine(t, sinkPath, `{"id":"1","type":"unknown-create","body":{}}`)
+	appendLine(t, sinkPath, `not json`)
+
+	var output bytes.Buffer
+	result, err := langfuse.Replay(context.TODO(), langfuse.NewJSONLSink(&output), []string{exportPath, sinkPath}, langfuse.ReplayOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, langfuse.ReplayResult{Read: 6, Sent: 4, Duplicates: 2, Unreadable: 2}, result)
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[2], `"type":"`+langfuse.EventTypeSpanUpdate+`"`)
+	assert.Contains(t, lines[3], `"type":"`+langfuse.EventTypeSpanUpdate+`"`)
+
+	// Replayed events keep their original envelope
+	var original, replayed struct {
+		Batch []json.RawMessage `json:"batch"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(exported[0]), &original))
+	require.NoError(t, json.Unmarshal([]byte("{\"batch\":["+strings.Join(lines[:2], ",")+"]}"), &replayed))
+	assert.JSONEq(t, string(original.Batch[0]), string(replayed.Batch[0]))
+	assert.JSONEq(t, string(original.Batch[1]), string(replayed.Batch[1]))
+}
+
+func Test_Replay_SendsInBatches(t *testing.T) {
+	testCases := []struct {
+		name             string
+		clientErr        error
+		expectedSent     int
+		expectedFailed   int
+		expectedReceived int
+	}{
+		{name: "events are sent", expectedSent: 5, expectedReceived: 5},
+		{name: "failed batches are counted and skipped", clientErr: langfuse.NewHTTPError(http.StatusBadRequest, "invalid"), expectedFailed: 5, expectedReceived: 5},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeExport(t, 5)
+			client := &stubClient{err: test.clientErr}
+			startTime := time.Now()
+
+			result, err := langfuse.Replay(context.TODO(), client, []string{path}, langfuse.ReplayOptions{BatchSize: 2, Rate: 50})
+
+			require.NoError(t, err)
+			// Five events at 50 events per second take at least 100ms
+			assert.GreaterOrEqual(t, time.Since(startTime), 100*time.Millisecond)
+			assert.Equal(t, test.expectedReceived, client.received)
+			assert.Equal(t, test.expectedSent, result.Sent)
+			assert.Equal(t, test.expectedFailed, result.Failed)
+		})
+	}
+}
+
+func Test_Replay_WhenContextDone_ReturnsError(t *testing.T) {
+	path := writeExport(t, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := langfuse.Replay(ctx, &stubClient{}, []string{path}, langfuse.ReplayOptions{BatchSize: 1})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, result.Sent)
+}
+
+// writeExport exports count trace events and returns the path of the export
+func writeExport(t *testing.T, count int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	exporter, err := langfuse.NewFileExporter(path)
+	require.NoError(t, err)
+	for range count {
+		eventID := uuid.New()
+		require.NoError(t, exporter.Send(context.TODO(), &types.TraceEvent{ID: &eventID, Name: "LLM"}))
+	}
+	require.NoError(t, exporter.Close())
+	return path
+}
+
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(line + "\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
@@ -0,0 +1,98 @@
+package langfuse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// sinkFileMode the permissions of files created by sinks, events may carry sensitive prompts
+const sinkFileMode = 0o600
+
+// JSONLSink a Client writing every event as a JSON line instead of sending it to Langfuse, e.g. to keep a local copy
+// while mirroring with NewFanOutClient or to inspect events during development. Each line holds the ingestion event
+// as sent to the API: its id, type, timestamp and body. Safe for concurrent use.
+type JSONLSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+// NewJSONLSink creates a sink writing the events to writer
+func NewJSONLSink(writer io.Writer) *JSONLSink {
+	return &JSONLSink{writer: writer}
+}
+
+// NewStdoutSink creates a sink writing the events to the standard output
+func NewStdoutSink() *JSONLSink {
+	return NewJSONLSink(os.Stdout)
+}
+
+// NewJSONLFileSink creates a sink appending the events to the file, which is created when missing.
+// Call Close once the service using the sink is stopped.
+func NewJSONLFileSink(path string) (*JSONLSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, sinkFileMode) // #nosec G304 -- the path is given by the application
+	if err != nil {
+		return nil, ErrInvalidConfig.WithCause(err).WithDetails(map[string]any{
+			"path": path,
+		})
+	}
+	return &JSONLSink{writer: file, closer: file}, nil
+}
+
+// Send writes the event as a JSON line
+func (s *JSONLSink) Send(ctx context.Context, event types.LangfuseEvent) error {
+	return s.SendBatch(ctx, []types.LangfuseEvent{event})
+}
+
+// SendBatch validates the events and writes them as JSON lines at once, so that lines of concurrent batches do not interleave
+func (s *JSONLSink) SendBatch(ctx context.Context, events []types.LangfuseEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	request, err := newIngestionRequest(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	var lines bytes.Buffer
+	encoder := json.NewEncoder(&lines)
+	for _, ingestionEvent := range request.Batch {
+		if err := encoder.Encode(ingestionEvent); err != nil {
+			return ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+				"operation": "json_marshal",
+				"event_id":  ingestionEvent.ID,
+			})
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.writer.Write(lines.Bytes()); err != nil {
+		return ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+			"operation": "write",
+		})
+	}
+	return nil
+}
+
+// Ping returns nil, the sink has no remote API
+func (s *JSONLSink) Ping(_ context.Context) error {
+	return nil
+}
+
+// Close closes the file of a sink created with NewJSONLFileSink, other sinks leave their writer open
+func (s *JSONLSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
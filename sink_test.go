@@ -0,0 +1,78 @@
+package langfuse_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_JSONLSink_WritesEventPerLine(t *testing.T) {
+	var output bytes.Buffer
+	sink := langfuse.NewJSONLSink(&output)
+	traceID := uuid.MustParse("50000000-0000-0000-0000-000000000001")
+	spanID := uuid.MustParse("50000000-0000-0000-0000-000000000002")
+
+	err := sink.SendBatch(context.TODO(), []types.LangfuseEvent{
+		&types.TraceEvent{ID: &traceID, Name: "LLM"},
+		&types.SpanEvent{ID: &spanID, TraceID: &traceID, Name: "retrieval"},
+	})
+
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	require.Len(t, lines, 2)
+	var line struct {
+		ID   string         `json:"id"`
+		Type string         `json:"type"`
+		Body map[string]any `json:"body"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	assert.NotEmpty(t, line.ID)
+	assert.Equal(t, spanID.String(), line.Body["id"])
+	assert.Equal(t, langfuse.EventTypeSpanCreate, line.Type)
+	assert.Equal(t, "retrieval", line.Body["name"])
+}
+
+func Test_JSONLSink_WhenEventInvalid_WritesNothing(t *testing.T) {
+	var output bytes.Buffer
+	sink := langfuse.NewJSONLSink(&output)
+	traceID := uuid.New()
+	scoreID := uuid.New()
+
+	err := sink.SendBatch(context.TODO(), []types.LangfuseEvent{
+		&types.TraceEvent{ID: &traceID, Name: "LLM"},
+		&types.ScoreEvent{ID: &scoreID},
+	})
+
+	var langfuseErr *langfuse.Error
+	require.ErrorAs(t, err, &langfuseErr)
+	assert.Equal(t, langfuse.ErrEventValidation.Code, langfuseErr.Code)
+	assert.Empty(t, output.String())
+}
+
+func Test_JSONLFileSink_AppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o600))
+	sink, err := langfuse.NewJSONLFileSink(path)
+	require.NoError(t, err)
+	eventID := uuid.New()
+
+	require.NoError(t, sink.Send(context.TODO(), &types.TraceEvent{ID: &eventID, Name: "LLM"}))
+	require.NoError(t, sink.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], eventID.String())
+}
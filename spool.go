@@ -0,0 +1,398 @@
+package langfuse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bdpiprava/GoLangfuse/logger"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+const (
+	spoolSegmentPrefix = "segment-"
+	spoolSegmentSuffix = ".jsonl"
+
+	defaultSpoolMaxBytes       = 100 << 20 // 100MB
+	defaultSpoolSegmentBytes   = 8 << 20   // 8MB
+	defaultSpoolReplayInterval = 30 * time.Second
+	defaultSpoolReplayRate     = 100 // events per second
+
+	spoolDirPerm  = 0o750
+	spoolFilePerm = 0o600
+)
+
+// spooledEvent an event as persisted in a spool segment, the body is decoded once the type is known
+type spooledEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// spool a durable on-disk queue for events that could not be delivered.
+// Events are appended as JSON lines to segment files which are replayed oldest first and removed once delivered.
+type spool struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+	metrics      *MetricsCollector
+
+	mu          sync.Mutex
+	closed      bool
+	current     *os.File
+	currentSize int64
+	sequence    int64
+	totalBytes  int64
+	depth       int64
+}
+
+// openSpool opens the spool in dir, creating it when missing and accounting for segments left by a previous run
+func openSpool(dir string, maxBytes, segmentBytes int64, metrics *MetricsCollector) (*spool, error) {
+	if err := os.MkdirAll(dir, spoolDirPerm); err != nil {
+		return nil, ErrInvalidConfig.WithCause(err).WithDetails(map[string]any{
+			"spool_dir": dir,
+		})
+	}
+
+	s := &spool{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		segmentBytes: segmentBytes,
+		metrics:      metrics,
+	}
+
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, segment := range segments {
+		info, err := os.Stat(segment)
+		if err != nil {
+			return nil, ErrEventProcessing.WithCause(err)
+		}
+		lines, err := countLines(segment)
+		if err != nil {
+			return nil, ErrEventProcessing.WithCause(err)
+		}
+		s.totalBytes += info.Size()
+		s.depth += lines
+	}
+
+	s.metrics.UpdateSpoolMetrics(s.depth, s.totalBytes)
+	return s, nil
+}
+
+// Write appends the events to the current segment, returns ErrSpoolFull when the size limit would be exceeded
+// and ErrServiceStopped once the spool is closed
+func (s *spool) Write(events []types.LangfuseEvent) error {
+	var buffer bytes.Buffer
+	for _, ingestionEvent := range events {
+		line, err := json.Marshal(newIngestionEvent(context.Background(), ingestionEvent, getEventType(ingestionEvent)))
+		if err != nil {
+			return ErrEventProcessing.WithCause(err)
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A closed spool does not start new segments, e.g. for a processor still running after a timed out Stop
+	if s.closed {
+		return ErrServiceStopped
+	}
+
+	size := int64(buffer.Len())
+	if s.totalBytes+size > s.maxBytes {
+		return ErrSpoolFull.WithDetails(map[string]any{
+			"spool_bytes": s.totalBytes,
+			"max_bytes":   s.maxBytes,
+		})
+	}
+
+	if s.current == nil || s.currentSize >= s.segmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.current.Write(buffer.Bytes()); err != nil {
+		return ErrEventProcessing.WithCause(err)
+	}
+	if err := s.current.Sync(); err != nil {
+		return ErrEventProcessing.WithCause(err)
+	}
+
+	s.currentSize += size
+	s.totalBytes += size
+	s.depth += int64(len(events))
+	s.metrics.IncrementEventsSpooled(len(events))
+	s.metrics.UpdateSpoolMetrics(s.depth, s.totalBytes)
+	return nil
+}
+
+// Replay sends spooled events oldest first in batches of at most batchSize events and maxBatchBytes bytes,
+// limited to rate events per second. A maxBatchBytes of 0 does not limit the batch size in bytes.
+// Delivered segments are removed, on failure the undelivered remainder is kept for the next replay.
+// Returns the number of events replayed.
+func (s *spool) Replay(
+	ctx context.Context,
+	batchSize, maxBatchBytes, rate int,
+	send func(context.Context, []types.LangfuseEvent) error,
+) (int, error) {
+	s.mu.Lock()
+	err := s.closeCurrent()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	segments, err := s.segments()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, segment := range segments {
+		count, err := s.replaySegment(ctx, segment, batchSize, maxBatchBytes, rate, send)
+		replayed += count
+		if err != nil {
+			return replayed, err
+		}
+	}
+	return replayed, nil
+}
+
+// replaySegment replays a single segment file, see Replay
+func (s *spool) replaySegment(
+	ctx context.Context,
+	segment string,
+	batchSize, maxBatchBytes, rate int,
+	send func(context.Context, []types.LangfuseEvent) error,
+) (int, error) {
+	log := logger.FromContext(ctx)
+	lines, err := readLines(segment)
+	if err != nil {
+		return 0, ErrEventProcessing.WithCause(err)
+	}
+
+	replayed := 0
+	for start, end := 0, 0; start < len(lines); start = end {
+		end = nextBatchEnd(lines, start, batchSize, maxBatchBytes)
+		events := make([]types.LangfuseEvent, 0, end-start)
+		for _, line := range lines[start:end] {
+			decoded, err := decodeSpooledEvent(line)
+			if err != nil {
+				log.WithError(err).Error("dropping unreadable event from langfuse spool")
+				s.metrics.IncrementEventsFailed(err)
+				continue
+			}
+			events = append(events, decoded.Body)
+		}
+
+		if len(events) > 0 {
+			if err := send(ctx, events); err != nil {
+				if rewriteErr := s.rewriteSegment(segment, lines[start:]); rewriteErr != nil {
+					return replayed, rewriteErr
+				}
+				return replayed, err
+			}
+		}
+
+		replayed += len(events)
+		s.release(int64(end-start), 0)
+
+		// Limit the replay rate so a recovering API is not flooded
+		select {
+		case <-ctx.Done():
+			if rewriteErr := s.rewriteSegment(segment, lines[end:]); rewriteErr != nil {
+				return replayed, rewriteErr
+			}
+			return replayed, ctx.Err()
+		case <-time.After(time.Duration(end-start) * time.Second / time.Duration(rate)):
+		}
+	}
+
+	info, err := os.Stat(segment)
+	if err != nil {
+		return replayed, ErrEventProcessing.WithCause(err)
+	}
+	if err := os.Remove(segment); err != nil {
+		return replayed, ErrEventProcessing.WithCause(err)
+	}
+	s.release(0, info.Size())
+	return replayed, nil
+}
+
+// nextBatchEnd returns the end of the batch starting at start, a batch always holds at least one line
+func nextBatchEnd(lines [][]byte, start, batchSize, maxBatchBytes int) int {
+	end, size := start, 0
+	for end < len(lines) && end-start < batchSize {
+		size += len(lines[end]) + 1 // separating comma
+		if maxBatchBytes > 0 && end > start && size > maxBatchBytes {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// rewriteSegment replaces the segment with the lines that are still undelivered
+func (s *spool) rewriteSegment(segment string, remaining [][]byte) error {
+	info, err := os.Stat(segment)
+	if err != nil {
+		return ErrEventProcessing.WithCause(err)
+	}
+
+	if len(remaining) == 0 {
+		if err := os.Remove(segment); err != nil {
+			return ErrEventProcessing.WithCause(err)
+		}
+		s.release(0, info.Size())
+		return nil
+	}
+
+	temp := segment + ".tmp"
+	content := append(bytes.Join(remaining, []byte("\n")), '\n')
+	if err := os.WriteFile(temp, content, spoolFilePerm); err != nil {
+		return ErrEventProcessing.WithCause(err)
+	}
+	if err := os.Rename(temp, segment); err != nil {
+		return ErrEventProcessing.WithCause(err)
+	}
+	s.release(0, info.Size()-int64(len(content)))
+	return nil
+}
+
+// release updates the accounting once events or bytes are no longer held by the spool
+func (s *spool) release(events, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.depth -= events
+	s.totalBytes -= size
+	s.metrics.UpdateSpoolMetrics(s.depth, s.totalBytes)
+}
+
+// Close closes the current segment, spooled events remain on disk for the next run and later writes are rejected
+func (s *spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.closeCurrent()
+}
+
+// rotate closes the current segment and starts a new one, must be called with the lock held
+func (s *spool) rotate() error {
+	if err := s.closeCurrent(); err != nil {
+		return err
+	}
+
+	// Use a monotonic sequence so segment names sort in write order
+	s.sequence = max(s.sequence+1, time.Now().UnixNano())
+	name := filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", spoolSegmentPrefix, s.sequence, spoolSegmentSuffix))
+	file, err := os.OpenFile(filepath.Clean(name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, spoolFilePerm)
+	if err != nil {
+		return ErrEventProcessing.WithCause(err)
+	}
+
+	s.current = file
+	s.currentSize = 0
+	return nil
+}
+
+// closeCurrent closes the segment being written, must be called with the lock held
+func (s *spool) closeCurrent() error {
+	if s.current == nil {
+		return nil
+	}
+
+	err := s.current.Close()
+	s.current = nil
+	s.currentSize = 0
+	if err != nil {
+		return ErrEventProcessing.WithCause(err)
+	}
+	return nil
+}
+
+// segments returns the closed segment files, oldest first
+func (s *spool) segments() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, ErrEventProcessing.WithCause(err)
+	}
+
+	s.mu.Lock()
+	var current string
+	if s.current != nil {
+		current = s.current.Name()
+	}
+	s.mu.Unlock()
+
+	var segments []string
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(s.dir, name)
+		if entry.IsDir() || path == current || !strings.HasPrefix(name, spoolSegmentPrefix) || !strings.HasSuffix(name, spoolSegmentSuffix) {
+			continue
+		}
+		segments = append(segments, path)
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// decodeSpooledEvent decodes a spooled line into the ingestion event with its typed body
+func decodeSpooledEvent(line []byte) (event, error) {
+	var spooled spooledEvent
+	if err := json.Unmarshal(line, &spooled); err != nil {
+		return event{}, ErrEventProcessing.WithCause(err)
+	}
+
+	decoded, err := newEventForType(spooled.Type)
+	if err != nil {
+		return event{}, err
+	}
+	if err := json.Unmarshal(spooled.Body, decoded); err != nil {
+		return event{}, ErrEventProcessing.WithCause(err).WithDetails(map[string]any{
+			"event_id": spooled.ID,
+		})
+	}
+	return event{ID: spooled.ID, Type: spooled.Type, Timestamp: spooled.Timestamp, Body: decoded}, nil
+}
+
+// readLines reads all non-empty lines of a file
+func readLines(path string) ([][]byte, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(content)+1)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// countLines counts the non-empty lines of a file
+func countLines(path string) (int64, error) {
+	lines, err := readLines(path)
+	return int64(len(lines)), err
+}
@@ -0,0 +1,189 @@
+package langfuse_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/mock"
+)
+
+func Test_Spool_ReplaysUndeliveredEventsOnceAPIRecovers(t *testing.T) {
+	transport := &switchableTransport{statusCode: http.StatusServiceUnavailable}
+	subject := langfuse.NewWithClient(spoolConfig(t.TempDir()), &http.Client{Transport: transport})
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	subject.Add(traceWithID("20000000-0000-0000-0000-000000000001"))
+
+	require.Eventually(t, func() bool {
+		return subject.GetMetrics().SpoolDepth == 1
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, int64(1), subject.GetMetrics().EventsSpooled)
+	assert.Equal(t, int64(0), subject.GetMetrics().EventsFailed)
+
+	transport.setStatusCode(http.StatusOK)
+
+	require.Eventually(t, func() bool {
+		metrics := subject.GetMetrics()
+		return metrics.EventsReplayed == 1 && metrics.SpoolBytes == 0
+	}, time.Second*5, time.Millisecond*10)
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(0), metrics.SpoolDepth)
+	assert.Equal(t, int64(1), metrics.EventsProcessed)
+	assert.Contains(t, transport.delivered(), `"id":"20000000-0000-0000-0000-000000000001"`)
+}
+
+func Test_Spool_WhenEventIsSpooled_CompletesItsDelivery(t *testing.T) {
+	transport := &switchableTransport{statusCode: http.StatusServiceUnavailable}
+	recorder := &hookRecorder{}
+	subject := langfuse.NewWithClient(spoolConfig(t.TempDir()), &http.Client{Transport: transport}, langfuse.WithDeliveryHooks(recorder.hooks()))
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	delivery := subject.AddEventWithResult(context.TODO(), traceWithID("20000000-0000-0000-0000-000000000011"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	result, err := delivery.Wait(ctx)
+	require.NoError(t, err, "the delivery completes before Stop")
+	assert.ErrorIs(t, result.Err, langfuse.ErrEventSpooled)
+	assert.Positive(t, result.Attempts)
+	assert.Empty(t, recorder.get("failed"))
+
+	transport.setStatusCode(http.StatusOK)
+	require.Eventually(t, func() bool { return len(recorder.get("delivered")) == 1 }, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, "20000000-0000-0000-0000-000000000011", recorder.get("delivered")[0].EventID.String())
+}
+
+func Test_Spool_ReplaysEventsLeftByPreviousRun(t *testing.T) {
+	dir := t.TempDir()
+	unavailable := &switchableTransport{statusCode: http.StatusServiceUnavailable}
+	previous := langfuse.NewWithClient(spoolConfig(dir), &http.Client{Transport: unavailable})
+	previous.Add(traceWithID("20000000-0000-0000-0000-000000000002"))
+	require.NoError(t, previous.Stop(context.TODO()))
+	require.Equal(t, int64(1), previous.GetMetrics().SpoolDepth)
+
+	available := &switchableTransport{statusCode: http.StatusOK}
+	subject := langfuse.NewWithClient(spoolConfig(dir), &http.Client{Transport: available})
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	require.Eventually(t, func() bool {
+		metrics := subject.GetMetrics()
+		return metrics.EventsReplayed == 1 && metrics.SpoolBytes == 0
+	}, time.Second*5, time.Millisecond*10)
+	assert.Contains(t, available.delivered(), `"id":"20000000-0000-0000-0000-000000000002"`)
+	assert.Equal(t, int64(0), subject.GetMetrics().SpoolDepth)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_Spool_WhenEventCannotBeSpooled_CountsEventAsFailed(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		maxBytes   int64
+	}{
+		{
+			name:       "spool size limit reached",
+			statusCode: http.StatusServiceUnavailable,
+			maxBytes:   10,
+		},
+		{
+			name:       "non-retryable error",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := spoolConfig(t.TempDir())
+			cfg.SpoolMaxBytes = test.maxBytes
+			transport := &switchableTransport{statusCode: test.statusCode}
+			subject := langfuse.NewWithClient(cfg, &http.Client{Transport: transport})
+
+			subject.Add(traceWithID("20000000-0000-0000-0000-000000000003"))
+			require.NoError(t, subject.Stop(context.TODO()))
+
+			metrics := subject.GetMetrics()
+			assert.Equal(t, int64(1), metrics.EventsFailed)
+			assert.Equal(t, int64(0), metrics.EventsSpooled)
+			assert.Equal(t, int64(0), metrics.SpoolDepth)
+		})
+	}
+}
+
+func Test_Spool_WhenStopTimedOut_DoesNotSpoolLateEvents(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		<-release
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+	subject := langfuse.NewWithClient(spoolConfig(dir), httpClient)
+	subject.Add(traceWithID("20000000-0000-0000-0000-000000000004"))
+	require.Eventually(t, func() bool {
+		return subject.GetMetrics().HTTPRequestsTotal == 0 && subject.GetMetrics().QueueSize == 0
+	}, time.Second, time.Millisecond*10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	require.ErrorIs(t, subject.Stop(ctx), context.DeadlineExceeded)
+	close(release)
+
+	require.Eventually(t, func() bool {
+		return subject.GetMetrics().EventsFailed == 1
+	}, time.Second*5, time.Millisecond*10)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Zero(t, subject.GetMetrics().EventsSpooled)
+}
+
+func spoolConfig(dir string) *config.Langfuse {
+	cfg := testConfig()
+	cfg.SpoolDir = dir
+	cfg.SpoolReplayInterval = time.Millisecond * 20
+	cfg.SpoolReplayRate = 1000
+	return cfg
+}
+
+// switchableTransport responds with the configured status code, recording the bodies of successful requests
+type switchableTransport struct {
+	mutex      sync.Mutex
+	statusCode int
+	bodies     []string
+}
+
+func (s *switchableTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(request.Body)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.statusCode == http.StatusOK {
+		s.bodies = append(s.bodies, string(body))
+	}
+	return &http.Response{StatusCode: s.statusCode, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func (s *switchableTransport) setStatusCode(statusCode int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.statusCode = statusCode
+}
+
+func (s *switchableTransport) delivered() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return strings.Join(s.bodies, "\n")
+}
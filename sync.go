@@ -0,0 +1,266 @@
+package langfuse
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/logger"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// syncProcessors the number of active processors reported by the synchronous implementation, the caller's goroutine
+const syncProcessors = 1
+
+// SyncLangfuse a Langfuse implementation sending every event on the caller's goroutine, without a queue,
+// background processors or batching. It suits scripts and CLI tools which exit right after their work is done.
+// Besides the Langfuse interface it exposes Send and SendBatch returning the outcome as a structured *Error.
+type SyncLangfuse interface {
+	Langfuse
+	// Send validates and sends the event, returns its ID, generating one if missing, and the error when it was not accepted
+	Send(ctx context.Context, event types.LangfuseEvent) (*uuid.UUID, error)
+	// SendBatch validates and sends the events in a single request, generating missing IDs.
+	// When only some events are rejected the returned error lists them, see Error.FailedEvents.
+	SendBatch(ctx context.Context, events []types.LangfuseEvent) error
+}
+
+// syncService sends the events on the caller's goroutine
+type syncService struct {
+	*serviceCore
+	stateMu  sync.RWMutex
+	stopped  bool
+	inFlight sync.WaitGroup
+}
+
+// NewSync initialise new Langfuse instance sending events on the caller's goroutine.
+// New and NewWithClient return it as well when SyncMode is configured.
+// The process exits when the config is invalid, use NewSyncE to handle the error instead.
+func NewSync(config *config.Langfuse, customHTTPClient *http.Client, opts ...Option) SyncLangfuse {
+	service, err := NewSyncE(config, customHTTPClient, opts...)
+	if err != nil {
+		logger := logger.FromContext(context.Background())
+		logger.Fatalf("invalid langfuse configuration: %v", err)
+	}
+	return service
+}
+
+// NewSyncE initialise new Langfuse instance like NewSync, returning an error when the config is invalid.
+// The error is an *Error with code INVALID_CONFIG, detailing the invalid field and the reason.
+func NewSyncE(config *config.Langfuse, customHTTPClient *http.Client, opts ...Option) (SyncLangfuse, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return newSyncService(config, customHTTPClient, opts), nil
+}
+
+// newSyncService creates the synchronous service for a valid config
+func newSyncService(config *config.Langfuse, customHTTPClient *http.Client, opts []Option) *syncService {
+	core := newServiceCore(config, customHTTPClient, opts)
+	core.metricsCollector.UpdateActiveProcessors(syncProcessors)
+	return &syncService{serviceCore: core}
+}
+
+func (s *syncService) Add(event types.LangfuseEvent) *uuid.UUID {
+	return s.AddEvent(context.Background(), event)
+}
+
+// AddEvent sends the event and returns its unique ID, generating one if missing. The error is logged, use Send to get it.
+func (s *syncService) AddEvent(ctx context.Context, event types.LangfuseEvent) *uuid.UUID {
+	id, err := s.Send(ctx, event)
+	if err != nil {
+		log := logger.FromContext(ctx)
+		log.WithError(err).Errorf("failed to send event %v", id)
+	}
+	return id
+}
+
+// AddEventWithResult sends the event and returns its Delivery, which is already complete
+func (s *syncService) AddEventWithResult(ctx context.Context, event types.LangfuseEvent) *Delivery {
+	_ = ensureEventID(event) // Send reports an update event without an ID through the delivery
+	delivery := s.track(event)
+	_ = s.AddEvent(ctx, event)
+	return delivery
+}
+
+// Send validates and sends the event, events sent after Stop are rejected with ErrServiceStopped
+// and update events without an ID with ErrInvalidEventID
+func (s *syncService) Send(ctx context.Context, event types.LangfuseEvent) (*uuid.UUID, error) {
+	if err := ensureEventID(event); err != nil {
+		s.eventFailed(s.metricsCollector, event, err, 0)
+		return nil, err
+	}
+
+	// Stop waits for in-flight sends to finish
+	if !s.beginSend() {
+		s.reject(event)
+		return event.GetID(), ErrServiceStopped
+	}
+	defer s.inFlight.Done()
+
+	if _, err := s.recordEventSize(event); err != nil {
+		s.eventFailed(s.metricsCollector, event, err, 0)
+		return event.GetID(), err
+	}
+
+	startTime := time.Now()
+	attemptCtx, attempts := withAttemptCounter(ctx)
+	err := s.client.Send(attemptCtx, event)
+	s.recordRequest(*attempts, err == nil, time.Since(startTime))
+
+	if err != nil {
+		s.eventFailed(s.metricsCollector, event, err, *attempts)
+		return event.GetID(), err
+	}
+	s.eventDelivered(s.metricsCollector, event, *attempts)
+	return event.GetID(), nil
+}
+
+// SendBatch validates and sends the events in a single request, events sent after Stop are rejected with ErrServiceStopped
+func (s *syncService) SendBatch(ctx context.Context, events []types.LangfuseEvent) error {
+	for _, event := range events {
+		if err := ensureEventID(event); err != nil {
+			s.batchFailed(events, err, 0)
+			return err
+		}
+	}
+
+	if !s.beginSend() {
+		for _, event := range events {
+			s.reject(event)
+		}
+		return ErrServiceStopped
+	}
+	defer s.inFlight.Done()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	for _, event := range events {
+		if _, err := s.recordEventSize(event); err != nil {
+			s.batchFailed(events, err, 0)
+			return err
+		}
+	}
+	s.metricsCollector.RecordBatchSize(len(events))
+
+	startTime := time.Now()
+	attemptCtx, attempts := withAttemptCounter(ctx)
+	err := s.client.SendBatch(attemptCtx, events)
+	failures := failedEvents(err)
+	s.recordRequest(*attempts, err == nil || len(failures) > 0, time.Since(startTime))
+
+	switch {
+	case err == nil:
+		s.metricsCollector.IncrementBatchesProcessed()
+		for _, event := range events {
+			s.eventDelivered(s.metricsCollector, event, *attempts)
+		}
+	case len(failures) > 0:
+		// The request succeeded but some events were rejected
+		s.metricsCollector.IncrementBatchesProcessed()
+		rejected := failuresByIndex(failures)
+		for i, event := range events {
+			if failure, found := rejected[i]; found {
+				s.eventFailed(s.metricsCollector, event, failure.Err(), *attempts)
+			} else {
+				s.eventDelivered(s.metricsCollector, event, *attempts)
+			}
+		}
+	default:
+		s.batchFailed(events, err, *attempts)
+	}
+	return err
+}
+
+// StartTrace starts a new trace and returns a context carrying it, the trace is sent when the handle ends
+func (s *syncService) StartTrace(ctx context.Context, name string) (context.Context, *TraceHandle) {
+	return startTrace(ctx, s, name)
+}
+
+// StartSpan starts a span linked to the trace and observation in the context
+func (s *syncService) StartSpan(ctx context.Context, name string) (context.Context, *SpanHandle) {
+	return startSpan(ctx, s, name)
+}
+
+// StartGeneration starts a generation linked to the trace and observation in the context
+func (s *syncService) StartGeneration(ctx context.Context, name string) (context.Context, *GenerationHandle) {
+	return startGeneration(ctx, s, name)
+}
+
+// Flush returns immediately as events are sent when they are added, ErrServiceStopped after Stop
+func (s *syncService) Flush(_ context.Context) error {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	if s.stopped {
+		return ErrServiceStopped
+	}
+	return nil
+}
+
+// Stop waits for in-flight sends to finish, events sent afterwards are rejected with ErrServiceStopped.
+// Returns the context error when it expired before the in-flight sends finished.
+func (s *syncService) Stop(ctx context.Context) error {
+	s.stateMu.Lock()
+	if s.stopped {
+		s.stateMu.Unlock()
+		return ErrServiceStopped
+	}
+	s.stopped = true
+	s.stateMu.Unlock()
+	s.metricsCollector.UpdateActiveProcessors(0)
+
+	sendsDone := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(sendsDone)
+	}()
+
+	log := logger.FromContext(ctx)
+	select {
+	case <-sendsDone:
+		log.Info("Langfuse service stopped")
+		return nil
+	case <-ctx.Done():
+		log.Warn("Langfuse service stop timed out waiting for in-flight sends")
+		return ctx.Err()
+	}
+}
+
+// beginSend registers an in-flight send, returns false after Stop. Call inFlight.Done once the send is complete.
+func (s *syncService) beginSend() bool {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	if s.stopped {
+		return false
+	}
+	s.inFlight.Add(1)
+	return true
+}
+
+// reject records an event sent after Stop
+func (s *syncService) reject(event types.LangfuseEvent) {
+	s.metricsCollector.IncrementEventsRejected(ErrServiceStopped)
+	s.eventDropped(event, ErrServiceStopped)
+}
+
+// batchFailed records a batch none of whose events will be delivered
+func (s *syncService) batchFailed(events []types.LangfuseEvent, err error, attempts int) {
+	s.metricsCollector.IncrementBatchesFailed(err)
+	for _, event := range events {
+		s.eventFailed(s.metricsCollector, event, err, attempts)
+	}
+}
+
+// recordRequest records the HTTP request metrics, unless no request was made, e.g. the event was invalid
+func (s *syncService) recordRequest(attempts int, success bool, responseTime time.Duration) {
+	if attempts > 0 {
+		s.metricsCollector.RecordHTTPRequest(success, responseTime)
+	}
+}
@@ -0,0 +1,179 @@
+package langfuse_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/mock"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_SyncLangfuse_Send(t *testing.T) {
+	scoreTraceID := "90000000-0000-0000-0000-000000000003"
+	testCases := []struct {
+		name              string
+		event             types.LangfuseEvent
+		statusCode        int
+		expectedErrCode   string
+		expectedProcessed int64
+		expectedFailed    int64
+		expectedRequests  int64
+	}{
+		{
+			name:              "accepted by the API",
+			event:             traceWithID("90000000-0000-0000-0000-000000000001"),
+			statusCode:        http.StatusOK,
+			expectedProcessed: 1,
+			expectedRequests:  1,
+		},
+		{
+			name:             "rejected by the API",
+			event:            traceWithID("90000000-0000-0000-0000-000000000002"),
+			statusCode:       http.StatusBadRequest,
+			expectedErrCode:  "REQUEST_FAILED",
+			expectedFailed:   1,
+			expectedRequests: 1,
+		},
+		{
+			name:            "invalid event is not sent",
+			event:           &types.ScoreEvent{TraceID: &scoreTraceID, Value: 0.3},
+			statusCode:      http.StatusOK,
+			expectedErrCode: "EVENT_VALIDATION",
+			expectedFailed:  1,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			subject := langfuse.NewSync(testConfig(), sequenceClient(test.statusCode))
+			defer func() { _ = subject.Stop(context.TODO()) }()
+
+			id, err := subject.Send(context.TODO(), test.event)
+
+			require.NotNil(t, id)
+			assert.Equal(t, test.event.GetID(), id)
+			if test.expectedErrCode == "" {
+				require.NoError(t, err)
+			} else {
+				var langfuseErr *langfuse.Error
+				require.ErrorAs(t, err, &langfuseErr)
+				assert.Equal(t, test.expectedErrCode, langfuseErr.Code)
+			}
+			metrics := subject.GetMetrics()
+			assert.Equal(t, test.expectedProcessed, metrics.EventsProcessed)
+			assert.Equal(t, test.expectedFailed, metrics.EventsFailed)
+			assert.Equal(t, test.expectedRequests, metrics.HTTPRequestsTotal)
+		})
+	}
+}
+
+func Test_SyncLangfuse_SendBatch_WhenPartiallyRejected_ReportsRejectedEvents(t *testing.T) {
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(request.Body)
+		envelopes := envelopeIDs(t, body)
+		response := fmt.Sprintf(`{"successes":[{"id":"%s","status":201}],"errors":[{"id":"%s","status":400,"message":"invalid body"}]}`,
+			envelopes["90000000-0000-0000-0000-000000000011"], envelopes["90000000-0000-0000-0000-000000000012"])
+		return &http.Response{StatusCode: http.StatusMultiStatus, Body: io.NopCloser(strings.NewReader(response))}, nil
+	})}
+	recorder := &hookRecorder{}
+	subject := langfuse.NewSync(testConfig(), httpClient, langfuse.WithDeliveryHooks(recorder.hooks()))
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	err := subject.SendBatch(context.TODO(), []types.LangfuseEvent{
+		traceWithID("90000000-0000-0000-0000-000000000011"),
+		traceWithID("90000000-0000-0000-0000-000000000012"),
+	})
+
+	var langfuseErr *langfuse.Error
+	require.ErrorAs(t, err, &langfuseErr)
+	require.Len(t, langfuseErr.FailedEvents(), 1)
+	assert.Equal(t, uuid.MustParse("90000000-0000-0000-0000-000000000012"), langfuseErr.FailedEvents()[0].EventID)
+
+	delivered := recorder.get("delivered")
+	require.Len(t, delivered, 1)
+	assert.Equal(t, uuid.MustParse("90000000-0000-0000-0000-000000000011"), delivered[0].EventID)
+	failed := recorder.get("failed")
+	require.Len(t, failed, 1)
+	assert.Equal(t, uuid.MustParse("90000000-0000-0000-0000-000000000012"), failed[0].EventID)
+	assert.ErrorContains(t, failed[0].Err, "CLIENT_ERROR")
+
+	metrics := subject.GetMetrics()
+	assert.Equal(t, int64(1), metrics.BatchesProcessed)
+	assert.Equal(t, uint64(1), metrics.BatchSizeHistogram.Count)
+}
+
+func Test_NewWithClient_WhenSyncModeConfigured_SendsOnCallerGoroutine(t *testing.T) {
+	cfg := testConfig()
+	cfg.SyncMode = true
+	subject := langfuse.NewWithClient(cfg, sequenceClient(http.StatusOK))
+	defer func() { _ = subject.Stop(context.TODO()) }()
+
+	delivery := subject.AddEventWithResult(context.TODO(), traceWithID("90000000-0000-0000-0000-000000000021"))
+
+	select {
+	case <-delivery.Done():
+	default:
+		require.Fail(t, "delivery is still pending after the event was added")
+	}
+	result, err := delivery.Wait(context.TODO())
+	require.NoError(t, err)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, int64(1), subject.GetMetrics().EventsProcessed)
+	assert.Equal(t, 1, subject.GetMetrics().ActiveProcessors)
+	require.NoError(t, subject.Flush(context.TODO()))
+}
+
+func Test_SyncLangfuse_WhenStopped_RejectsEvents(t *testing.T) {
+	recorder := &hookRecorder{}
+	subject := langfuse.NewSync(testConfig(), sequenceClient(http.StatusOK), langfuse.WithDeliveryHooks(recorder.hooks()))
+	require.NoError(t, subject.Stop(context.TODO()))
+
+	_, sendErr := subject.Send(context.TODO(), traceWithID("90000000-0000-0000-0000-000000000031"))
+	batchErr := subject.SendBatch(context.TODO(), []types.LangfuseEvent{traceWithID("90000000-0000-0000-0000-000000000032")})
+
+	assert.ErrorIs(t, sendErr, langfuse.ErrServiceStopped)
+	assert.ErrorIs(t, batchErr, langfuse.ErrServiceStopped)
+	assert.ErrorIs(t, subject.Flush(context.TODO()), langfuse.ErrServiceStopped)
+	assert.ErrorIs(t, subject.Stop(context.TODO()), langfuse.ErrServiceStopped)
+	assert.Len(t, recorder.get("dropped"), 2)
+	assert.Equal(t, int64(2), subject.GetMetrics().EventsRejected)
+	assert.Zero(t, subject.GetMetrics().ActiveProcessors)
+}
+
+func Test_SyncLangfuse_Stop_WhenSendInFlight_HonoursContext(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	httpClient := &http.Client{Transport: mock.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+	subject := langfuse.NewSync(testConfig(), httpClient)
+	sendErr := make(chan error, 1)
+	go func() {
+		_, err := subject.Send(context.TODO(), traceWithID("90000000-0000-0000-0000-000000000041"))
+		sendErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	stopErr := subject.Stop(ctx)
+	close(release)
+
+	assert.ErrorIs(t, stopErr, context.DeadlineExceeded)
+	require.NoError(t, <-sendErr, "the in-flight send completes after Stop")
+	_, err := subject.Send(context.TODO(), traceWithID("90000000-0000-0000-0000-000000000042"))
+	assert.ErrorIs(t, err, langfuse.ErrServiceStopped)
+}
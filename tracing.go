@@ -0,0 +1,192 @@
+package langfuse
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+// observationContextKey is the context key under which the current trace and observation are stored
+type observationContextKey struct{}
+
+// observationContext the trace and observation an instrumented call is currently running in
+type observationContext struct {
+	traceID       uuid.UUID
+	observationID *uuid.UUID
+}
+
+// ContextWithTraceID returns a copy of ctx linked to an existing trace, e.g. one propagated from an upstream service.
+// Spans and generations started from the returned context are created within that trace.
+func ContextWithTraceID(ctx context.Context, traceID uuid.UUID) context.Context {
+	return context.WithValue(ctx, observationContextKey{}, observationContext{traceID: traceID})
+}
+
+// TraceIDFromContext returns the ID of the trace the context is running in, if any
+func TraceIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	current, ok := ctx.Value(observationContextKey{}).(observationContext)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return current.traceID, true
+}
+
+// ObservationIDFromContext returns the ID of the span or generation the context is running in, if any
+func ObservationIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	current, ok := ctx.Value(observationContextKey{}).(observationContext)
+	if !ok || current.observationID == nil {
+		return uuid.Nil, false
+	}
+	return *current.observationID, true
+}
+
+// TraceHandle a trace started with StartTrace, the trace is sent to langfuse when End is called
+type TraceHandle struct {
+	service Langfuse
+	ctx     context.Context
+	event   *types.TraceEvent
+	once    sync.Once
+}
+
+// ID returns the trace ID
+func (h *TraceHandle) ID() uuid.UUID {
+	return *h.event.ID
+}
+
+// Event returns the underlying trace event so that additional fields can be set before End is called
+func (h *TraceHandle) Event() *types.TraceEvent {
+	return h.event
+}
+
+// End adds the trace to the langfuse queue, calling End more than once has no effect
+func (h *TraceHandle) End() {
+	h.once.Do(func() {
+		h.service.AddEvent(h.ctx, h.event)
+	})
+}
+
+// SpanHandle a span started with StartSpan, the span is sent to langfuse when End or Error is called
+type SpanHandle struct {
+	service Langfuse
+	ctx     context.Context
+	event   *types.SpanEvent
+	once    sync.Once
+}
+
+// ID returns the span ID
+func (h *SpanHandle) ID() uuid.UUID {
+	return *h.event.ID
+}
+
+// TraceID returns the ID of the trace the span belongs to
+func (h *SpanHandle) TraceID() uuid.UUID {
+	return *h.event.TraceID
+}
+
+// Event returns the underlying span event so that additional fields can be set before End is called
+func (h *SpanHandle) Event() *types.SpanEvent {
+	return h.event
+}
+
+// End sets the end time and adds the span to the langfuse queue, calling End more than once has no effect
+func (h *SpanHandle) End() {
+	h.once.Do(func() {
+		h.service.AddEvent(h.ctx, h.event.End())
+	})
+}
+
+// Error sets the level to error with the status message and ends the span
+func (h *SpanHandle) Error(statusMessage string) {
+	h.once.Do(func() {
+		h.service.AddEvent(h.ctx, h.event.Error(statusMessage))
+	})
+}
+
+// GenerationHandle a generation started with StartGeneration, the generation is sent to langfuse when End or Error is called
+type GenerationHandle struct {
+	service Langfuse
+	ctx     context.Context
+	event   *types.GenerationEvent
+	once    sync.Once
+}
+
+// ID returns the generation ID
+func (h *GenerationHandle) ID() uuid.UUID {
+	return *h.event.ID
+}
+
+// TraceID returns the ID of the trace the generation belongs to
+func (h *GenerationHandle) TraceID() uuid.UUID {
+	return *h.event.TraceID
+}
+
+// Event returns the underlying generation event so that additional fields can be set before End is called
+func (h *GenerationHandle) Event() *types.GenerationEvent {
+	return h.event
+}
+
+// End sets the end time and adds the generation to the langfuse queue, calling End more than once has no effect
+func (h *GenerationHandle) End() {
+	h.once.Do(func() {
+		h.service.AddEvent(h.ctx, h.event.End())
+	})
+}
+
+// Error sets the level to error with the status message and ends the generation
+func (h *GenerationHandle) Error(statusMessage string) {
+	h.once.Do(func() {
+		h.service.AddEvent(h.ctx, h.event.Error("%s", statusMessage))
+	})
+}
+
+// startTrace starts a new trace and returns a context carrying it
+func startTrace(ctx context.Context, service Langfuse, name string) (context.Context, *TraceHandle) {
+	traceID := uuid.New()
+	event := types.NewTrace(name).WithID(traceID).Build()
+	return ContextWithTraceID(ctx, traceID), &TraceHandle{service: service, ctx: ctx, event: event}
+}
+
+// startSpan starts a new span within the trace and observation found in the context
+func startSpan(ctx context.Context, service Langfuse, name string) (context.Context, *SpanHandle) {
+	traceID, parentID := observationFromContext(ctx, service, name)
+	spanID := uuid.New()
+	now := time.Now().UTC()
+	event := &types.SpanEvent{
+		ID:                  &spanID,
+		TraceID:             &traceID,
+		ParentObservationID: parentID,
+		Name:                name,
+		StartTime:           &now,
+	}
+	return contextWithObservation(ctx, traceID, spanID), &SpanHandle{service: service, ctx: ctx, event: event}
+}
+
+// startGeneration starts a new generation within the trace and observation found in the context
+func startGeneration(ctx context.Context, service Langfuse, name string) (context.Context, *GenerationHandle) {
+	traceID, parentID := observationFromContext(ctx, service, name)
+	generationID := uuid.New()
+	builder := types.NewGeneration().WithID(generationID).WithTraceID(traceID).WithName(name)
+	if parentID != nil {
+		builder = builder.WithParentObservation(*parentID)
+	}
+	return contextWithObservation(ctx, traceID, generationID), &GenerationHandle{service: service, ctx: ctx, event: builder.Build()}
+}
+
+// observationFromContext returns the current trace and parent observation. When the context carries no trace, a trace
+// named after the observation is created and added to the queue so that the observation is not orphaned.
+func observationFromContext(ctx context.Context, service Langfuse, name string) (uuid.UUID, *uuid.UUID) {
+	current, ok := ctx.Value(observationContextKey{}).(observationContext)
+	if !ok {
+		traceID := uuid.New()
+		service.AddEvent(ctx, types.NewTrace(name).WithID(traceID).Build())
+		return traceID, nil
+	}
+	return current.traceID, current.observationID
+}
+
+func contextWithObservation(ctx context.Context, traceID, observationID uuid.UUID) context.Context {
+	return context.WithValue(ctx, observationContextKey{}, observationContext{traceID: traceID, observationID: &observationID})
+}
@@ -0,0 +1,125 @@
+package langfuse_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	langfuse "github.com/bdpiprava/GoLangfuse"
+	"github.com/bdpiprava/GoLangfuse/config"
+	"github.com/bdpiprava/GoLangfuse/mock"
+	"github.com/bdpiprava/GoLangfuse/types"
+)
+
+func Test_StartSpan_LinksObservationsThroughContext(t *testing.T) {
+	httpClient := &http.Client{}
+	mockTransport := mock.AddMockTransport(t, httpClient)
+	for range 3 {
+		resp := &http.Response{Body: io.NopCloser(strings.NewReader("{}"))}
+		mockTransport.ExpectWith("POST", "http://localhost:3000/api/public/ingestion").Return(resp, nil)
+	}
+	subject := langfuse.NewWithClient(testConfig(), httpClient)
+
+	ctx, trace := subject.StartTrace(context.TODO(), "agent-run")
+	spanCtx, span := subject.StartSpan(ctx, "plan")
+	_, generation := subject.StartGeneration(spanCtx, "llm-call")
+	generation.End()
+	span.End()
+	trace.End()
+
+	require.Eventually(t, mockTransport.AllExpectationMet, time.Second*5, time.Millisecond*50)
+	events := map[string]map[string]any{}
+	for _, request := range mockTransport.RecordedRequests() {
+		var body struct {
+			Batch []struct {
+				Type string         `json:"type"`
+				Body map[string]any `json:"body"`
+			} `json:"batch"`
+		}
+		require.NoError(t, json.NewDecoder(request.Body).Decode(&body))
+		for _, item := range body.Batch {
+			events[item.Type] = item.Body
+		}
+	}
+
+	require.Len(t, events, 3)
+	assert.Equal(t, trace.ID().String(), events["trace-create"]["id"])
+	assert.Equal(t, trace.ID().String(), events["span-create"]["traceId"])
+	assert.Nil(t, events["span-create"]["parentObservationId"])
+	assert.NotNil(t, events["span-create"]["endTime"])
+	assert.Equal(t, trace.ID().String(), events["generation-create"]["traceId"])
+	assert.Equal(t, span.ID().String(), events["generation-create"]["parentObservationId"])
+}
+
+func Test_StartSpan_WhenContextHasNoTrace_CreatesTrace(t *testing.T) {
+	testCases := []struct {
+		name  string
+		start func(ctx context.Context, subject langfuse.Langfuse) uuid.UUID
+	}{
+		{
+			name: "span",
+			start: func(ctx context.Context, subject langfuse.Langfuse) uuid.UUID {
+				_, span := subject.StartSpan(ctx, "orphan")
+				span.End()
+				return span.TraceID()
+			},
+		},
+		{
+			name: "generation",
+			start: func(ctx context.Context, subject langfuse.Langfuse) uuid.UUID {
+				_, generation := subject.StartGeneration(ctx, "orphan")
+				generation.End()
+				return generation.TraceID()
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			subject := langfuse.NewRecorder()
+
+			traceID := test.start(context.TODO(), subject)
+
+			subject.AssertEventTypeCount(t, langfuse.EventTypeTraceCreate, 1)
+			recorded, found := subject.Event(traceID)
+			require.True(t, found)
+			trace, ok := recorded.(*types.TraceEvent)
+			require.True(t, ok)
+			assert.Equal(t, "orphan", trace.Name)
+			subject.AssertTraceEventCount(t, traceID.String(), 2)
+		})
+	}
+}
+
+func Test_TraceIDFromContext(t *testing.T) {
+	traceID := uuid.New()
+
+	_, found := langfuse.TraceIDFromContext(context.TODO())
+	assert.False(t, found)
+
+	got, found := langfuse.TraceIDFromContext(langfuse.ContextWithTraceID(context.TODO(), traceID))
+	assert.True(t, found)
+	assert.Equal(t, traceID, got)
+
+	_, found = langfuse.ObservationIDFromContext(langfuse.ContextWithTraceID(context.TODO(), traceID))
+	assert.False(t, found)
+}
+
+func testConfig() *config.Langfuse {
+	return &config.Langfuse{
+		URL:                    "http://localhost:3000",
+		PublicKey:              "LangfusePublicKey",
+		SecretKey:              "LangfuseSecretKey",
+		NumberOfEventProcessor: 1,
+		BatchSize:              10,
+		BatchTimeout:           time.Millisecond * 50,
+	}
+}
@@ -0,0 +1,186 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventEvent An event represents a discrete, point-in-time observation in a trace, e.g. "tool selected" or "cache hit".
+// Usually, you want to add an event nested within a trace. Optionally you can nest it within another observation by
+// providing a parent_observation_id. If no trace_id is provided, a new trace is created just for this event.
+// Fields:
+//   - ID the id of the event can be set, otherwise a random id is generated.
+//   - TraceID trace id where this event needs to be created.
+//   - ParentObservationID the ID of the parent observation, if applicable.
+//   - Name identifier of the event. Useful for sorting/filtering in the UI.
+//   - StartTime the time at which the event happened, defaults to the current time.
+//   - Metadata of the event. It is merged when being updated via the API.
+//   - Level the level of the event. Used for sorting/filtering of traces with elevated error levels and for highlighting in the UI.
+//   - StatusMessage the additional field for context of the event. E.g. the error message of an error event.
+//   - Input the input to the event. Can be any JSON object.
+//   - Output the output to the event. Can be any JSON object.
+//   - Version the version of the event type. Used to understand how changes to the event type affect metrics. Useful in debugging.
+//   - Environment the environment in which the trace was created, e.g. "production", "staging", etc.
+type EventEvent struct {
+	ID                  *uuid.UUID     `json:"id" valid:"-"`
+	TraceID             *uuid.UUID     `json:"traceId,omitempty" valid:"-"`
+	ParentObservationID *uuid.UUID     `json:"parentObservationId,omitempty" valid:"-"`
+	Name                string         `json:"name,omitempty" valid:"-"`
+	StartTime           *time.Time     `json:"startTime,omitempty" valid:"-"`
+	Metadata            map[string]any `json:"metadata,omitempty" valid:"-"`
+	Level               Level          `json:"level,omitempty" valid:"-"`
+	StatusMessage       string         `json:"statusMessage,omitempty" valid:"-"`
+	Input               any            `json:"input,omitempty" valid:"-"`
+	Output              any            `json:"output,omitempty" valid:"-"`
+	Version             string         `json:"version,omitempty" valid:"-"`
+	Environment         string         `json:"environment,omitempty" valid:"-"`
+}
+
+// GetID return an event ID
+func (t *EventEvent) GetID() *uuid.UUID {
+	return t.ID
+}
+
+// SetID set event ID
+func (t *EventEvent) SetID(id *uuid.UUID) {
+	t.ID = id
+}
+
+// Clone creates a deep copy of the EventEvent
+func (t *EventEvent) Clone() LangfuseEvent {
+	if t == nil {
+		return nil
+	}
+
+	clone := &EventEvent{
+		Name:          t.Name,
+		Level:         t.Level,
+		StatusMessage: t.StatusMessage,
+		Version:       t.Version,
+		Environment:   t.Environment,
+	}
+
+	// Deep copy pointer fields
+	if t.ID != nil {
+		id := *t.ID
+		clone.ID = &id
+	}
+
+	if t.TraceID != nil {
+		traceID := *t.TraceID
+		clone.TraceID = &traceID
+	}
+
+	if t.ParentObservationID != nil {
+		parentID := *t.ParentObservationID
+		clone.ParentObservationID = &parentID
+	}
+
+	if t.StartTime != nil {
+		startTime := *t.StartTime
+		clone.StartTime = &startTime
+	}
+
+	// Deep copy map
+	if t.Metadata != nil {
+		clone.Metadata = make(map[string]any, len(t.Metadata))
+		for k, v := range t.Metadata {
+			clone.Metadata[k] = deepCopyAny(v)
+		}
+	}
+
+	// Deep copy any fields
+	clone.Input = deepCopyAny(t.Input)
+	clone.Output = deepCopyAny(t.Output)
+
+	return clone
+}
+
+// EventBuilder provides a fluent interface for building EventEvent
+type EventBuilder struct {
+	event *EventEvent
+}
+
+// NewEvent creates a new EventBuilder
+func NewEvent(name string) *EventBuilder {
+	now := time.Now().UTC()
+	return &EventBuilder{
+		event: &EventEvent{
+			Name:      name,
+			StartTime: &now,
+			Level:     Default,
+		},
+	}
+}
+
+// WithID sets the event ID
+func (b *EventBuilder) WithID(id uuid.UUID) *EventBuilder {
+	b.event.ID = &id
+	return b
+}
+
+// WithTraceID sets the trace ID
+func (b *EventBuilder) WithTraceID(traceID uuid.UUID) *EventBuilder {
+	b.event.TraceID = &traceID
+	return b
+}
+
+// WithParentObservation sets the parent observation ID
+func (b *EventBuilder) WithParentObservation(parentID uuid.UUID) *EventBuilder {
+	b.event.ParentObservationID = &parentID
+	return b
+}
+
+// WithStartTime sets the time at which the event happened
+func (b *EventBuilder) WithStartTime(startTime time.Time) *EventBuilder {
+	b.event.StartTime = &startTime
+	return b
+}
+
+// WithInput sets the input
+func (b *EventBuilder) WithInput(input any) *EventBuilder {
+	b.event.Input = input
+	return b
+}
+
+// WithOutput sets the output
+func (b *EventBuilder) WithOutput(output any) *EventBuilder {
+	b.event.Output = output
+	return b
+}
+
+// WithMetadata sets the metadata
+func (b *EventBuilder) WithMetadata(metadata map[string]any) *EventBuilder {
+	b.event.Metadata = metadata
+	return b
+}
+
+// WithLevel sets the level
+func (b *EventBuilder) WithLevel(level Level) *EventBuilder {
+	b.event.Level = level
+	return b
+}
+
+// WithStatusMessage sets the status message
+func (b *EventBuilder) WithStatusMessage(statusMessage string) *EventBuilder {
+	b.event.StatusMessage = statusMessage
+	return b
+}
+
+// WithVersion sets the version
+func (b *EventBuilder) WithVersion(version string) *EventBuilder {
+	b.event.Version = version
+	return b
+}
+
+// WithEnvironment sets the environment
+func (b *EventBuilder) WithEnvironment(environment string) *EventBuilder {
+	b.event.Environment = environment
+	return b
+}
+
+// Build returns the built EventEvent
+func (b *EventBuilder) Build() *EventEvent {
+	return b.event
+}
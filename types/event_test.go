@@ -0,0 +1,113 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventEvent_Clone(t *testing.T) {
+	testID := uuid.New()
+	testTraceID := uuid.New()
+	testParentID := uuid.New()
+	testStartTime := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		input      *EventEvent
+		want       *EventEvent
+		validateFn func(t *testing.T, original, clone *EventEvent)
+	}{
+		{
+			name:  "nil event returns nil",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name:  "empty event",
+			input: &EventEvent{},
+			want:  &EventEvent{},
+		},
+		{
+			name: "event with all fields populated",
+			input: &EventEvent{
+				ID:                  &testID,
+				TraceID:             &testTraceID,
+				ParentObservationID: &testParentID,
+				Name:                "cache-hit",
+				StartTime:           &testStartTime,
+				Metadata:            map[string]any{"cache": "redis", "keys": []any{"a", "b"}},
+				Level:               Debug,
+				StatusMessage:       "served from cache",
+				Input:               map[string]any{"key": "user:1"},
+				Output:              map[string]any{"hit": true},
+				Version:             "1.0",
+				Environment:         "production",
+			},
+			want: &EventEvent{
+				ID:                  &testID,
+				TraceID:             &testTraceID,
+				ParentObservationID: &testParentID,
+				Name:                "cache-hit",
+				StartTime:           &testStartTime,
+				Metadata:            map[string]any{"cache": "redis", "keys": []any{"a", "b"}},
+				Level:               Debug,
+				StatusMessage:       "served from cache",
+				Input:               map[string]any{"key": "user:1"},
+				Output:              map[string]any{"hit": true},
+				Version:             "1.0",
+				Environment:         "production",
+			},
+			validateFn: func(t *testing.T, original, clone *EventEvent) {
+				original.Metadata["cache"] = modifiedText
+				original.Metadata["keys"].([]any)[0] = modifiedText
+				assert.Equal(t, "redis", clone.Metadata["cache"], "Metadata should be deep copied")
+				assert.Equal(t, "a", clone.Metadata["keys"].([]any)[0], "Nested metadata should be deep copied")
+
+				assert.NotSame(t, original.ID, clone.ID, "ID pointers should be different")
+				assert.NotSame(t, original.TraceID, clone.TraceID, "TraceID pointers should be different")
+				assert.NotSame(t, original.ParentObservationID, clone.ParentObservationID, "ParentObservationID pointers should be different")
+				assert.NotSame(t, original.StartTime, clone.StartTime, "StartTime pointers should be different")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.Clone()
+
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			clonedEvent, ok := got.(*EventEvent)
+			require.True(t, ok, "Clone should return *EventEvent")
+
+			assert.Equal(t, tt.want, clonedEvent)
+
+			if tt.validateFn != nil {
+				tt.validateFn(t, tt.input, clonedEvent)
+			}
+		})
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	traceID := uuid.New()
+
+	got := NewEvent("tool-selected").
+		WithTraceID(traceID).
+		WithMetadata(map[string]any{"tool": "search"}).
+		Build()
+
+	assert.Equal(t, "tool-selected", got.Name)
+	assert.Equal(t, &traceID, got.TraceID)
+	assert.Equal(t, Default, got.Level)
+	assert.NotNil(t, got.StartTime)
+	assert.Equal(t, map[string]any{"tool": "search"}, got.Metadata)
+}
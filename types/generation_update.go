@@ -0,0 +1,215 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GenerationUpdateEvent A partial update of a generation that was previously created with GenerationEvent.
+// Only the fields that are set are sent to langfuse, so fields which are not set keep the values
+// they were created with. The ID is required and must match the ID of the generation being updated, events without it
+// are rejected with INVALID_EVENT_ID.
+// Fields:
+//   - ID the id of the generation to update.
+//   - Name identifier of the generation. Useful for sorting/filtering in the UI.
+//   - TraceID the trace ID associated with this generation, optional for updates.
+//   - StartTime the time at which the generation started.
+//   - CompletionStartTime The time at which the completion started. Used for latency analytics broken down into time until completion started and completion duration.
+//   - EndTime the time at which the generation ended.
+//   - Metadata additional metadata of the generation. Can be any JSON object. Metadata is merged when being updated via the API.
+//   - Model the name of the model used for the generation.
+//   - Input the prompt used for the generation. Can be any string or JSON object.
+//   - Output the completion generated by the model. Can be any string or JSON object.
+//   - Level the level of the generation. Used for sorting/filtering of traces with elevated error levels and for highlighting in the UI.
+//   - StatusMessage the additional field for context of the event. E.g. the error message of an error event.
+//   - ParentObservationID the ID of the parent observation, if applicable.
+//   - Version the version of the generation type. Used to understand how changes to the span type affect metrics. Useful in debugging.
+//   - ModelParameters the parameters of the model used for the generation, can be any key-value pairs.
+//   - Usage the usage object, only sent when set.
+//   - PromptVersion a prompt version
+//   - PromptName a prompt name
+type GenerationUpdateEvent struct {
+	ID                  *uuid.UUID     `json:"id" valid:"-"`
+	Name                string         `json:"name,omitempty" valid:"-"`
+	TraceID             *uuid.UUID     `json:"traceId,omitempty" valid:"-"`
+	StartTime           *time.Time     `json:"startTime,omitempty" valid:"-"`
+	CompletionStartTime *time.Time     `json:"completionStartTime,omitempty" valid:"-"`
+	EndTime             *time.Time     `json:"endTime,omitempty" valid:"-"`
+	Metadata            map[string]any `json:"metadata,omitempty" valid:"-"`
+	Model               string         `json:"model,omitempty" valid:"-"`
+	Input               any            `json:"input,omitempty" valid:"-"`
+	Output              any            `json:"output,omitempty" valid:"-"`
+	Level               Level          `json:"level,omitempty" valid:"-"`
+	StatusMessage       string         `json:"statusMessage,omitempty" valid:"-"`
+	ParentObservationID *uuid.UUID     `json:"parentObservationId,omitempty" valid:"-"`
+	Version             string         `json:"version,omitempty" valid:"-"`
+	ModelParameters     map[string]any `json:"modelParameters,omitempty" valid:"-"`
+	Usage               *Usage         `json:"usage,omitempty" valid:"-"`
+	PromptVersion       int            `json:"promptVersion,omitempty" valid:"range(0|9999)"`
+	PromptName          string         `json:"promptName,omitempty" valid:"-"`
+}
+
+// GetID return an event ID
+func (t *GenerationUpdateEvent) GetID() *uuid.UUID {
+	return t.ID
+}
+
+// SetID set event ID
+func (t *GenerationUpdateEvent) SetID(id *uuid.UUID) {
+	t.ID = id
+}
+
+// Clone creates a deep copy of the GenerationUpdateEvent
+func (t *GenerationUpdateEvent) Clone() LangfuseEvent {
+	if t == nil {
+		return nil
+	}
+
+	clone := &GenerationUpdateEvent{
+		Name:          t.Name,
+		Model:         t.Model,
+		Level:         t.Level,
+		StatusMessage: t.StatusMessage,
+		Version:       t.Version,
+		PromptVersion: t.PromptVersion,
+		PromptName:    t.PromptName,
+	}
+
+	// Deep copy pointer fields
+	if t.ID != nil {
+		id := *t.ID
+		clone.ID = &id
+	}
+
+	if t.TraceID != nil {
+		traceID := *t.TraceID
+		clone.TraceID = &traceID
+	}
+
+	if t.ParentObservationID != nil {
+		parentID := *t.ParentObservationID
+		clone.ParentObservationID = &parentID
+	}
+
+	if t.StartTime != nil {
+		startTime := *t.StartTime
+		clone.StartTime = &startTime
+	}
+
+	if t.CompletionStartTime != nil {
+		completionStartTime := *t.CompletionStartTime
+		clone.CompletionStartTime = &completionStartTime
+	}
+
+	if t.EndTime != nil {
+		endTime := *t.EndTime
+		clone.EndTime = &endTime
+	}
+
+	if t.Usage != nil {
+		usage := *t.Usage
+		clone.Usage = &usage
+	}
+
+	// Deep copy maps
+	if t.Metadata != nil {
+		clone.Metadata = make(map[string]any, len(t.Metadata))
+		for k, v := range t.Metadata {
+			clone.Metadata[k] = deepCopyAny(v)
+		}
+	}
+
+	if t.ModelParameters != nil {
+		clone.ModelParameters = make(map[string]any, len(t.ModelParameters))
+		for k, v := range t.ModelParameters {
+			clone.ModelParameters[k] = deepCopyAny(v)
+		}
+	}
+
+	// Deep copy any fields
+	clone.Input = deepCopyAny(t.Input)
+	clone.Output = deepCopyAny(t.Output)
+
+	return clone
+}
+
+// Error set Level to error and EndTime with status message
+func (t *GenerationUpdateEvent) Error(statusMessage string, args ...any) *GenerationUpdateEvent {
+	t.StatusMessage = fmt.Sprintf(statusMessage, args...)
+	t.Level = Error
+	return t.End()
+}
+
+// End set end time to now
+func (t *GenerationUpdateEvent) End() *GenerationUpdateEvent {
+	now := time.Now().UTC()
+	t.EndTime = &now
+	return t
+}
+
+// GenerationUpdateBuilder provides a fluent interface for building GenerationUpdateEvent
+type GenerationUpdateBuilder struct {
+	update *GenerationUpdateEvent
+}
+
+// NewGenerationUpdate creates a new GenerationUpdateBuilder for the generation with the given ID
+func NewGenerationUpdate(id uuid.UUID) *GenerationUpdateBuilder {
+	return &GenerationUpdateBuilder{
+		update: &GenerationUpdateEvent{ID: &id},
+	}
+}
+
+// WithTraceID sets the trace ID
+func (b *GenerationUpdateBuilder) WithTraceID(traceID uuid.UUID) *GenerationUpdateBuilder {
+	b.update.TraceID = &traceID
+	return b
+}
+
+// WithEndTime sets the end time
+func (b *GenerationUpdateBuilder) WithEndTime(endTime time.Time) *GenerationUpdateBuilder {
+	b.update.EndTime = &endTime
+	return b
+}
+
+// WithCompletionStartTime sets the completion start time
+func (b *GenerationUpdateBuilder) WithCompletionStartTime(completionStartTime time.Time) *GenerationUpdateBuilder {
+	b.update.CompletionStartTime = &completionStartTime
+	return b
+}
+
+// WithOutput sets the output
+func (b *GenerationUpdateBuilder) WithOutput(output any) *GenerationUpdateBuilder {
+	b.update.Output = output
+	return b
+}
+
+// WithLevel sets the level
+func (b *GenerationUpdateBuilder) WithLevel(level Level) *GenerationUpdateBuilder {
+	b.update.Level = level
+	return b
+}
+
+// WithStatusMessage sets the status message
+func (b *GenerationUpdateBuilder) WithStatusMessage(statusMessage string) *GenerationUpdateBuilder {
+	b.update.StatusMessage = statusMessage
+	return b
+}
+
+// WithUsage sets the usage
+func (b *GenerationUpdateBuilder) WithUsage(usage Usage) *GenerationUpdateBuilder {
+	b.update.Usage = &usage
+	return b
+}
+
+// WithMetadata sets the metadata
+func (b *GenerationUpdateBuilder) WithMetadata(metadata map[string]any) *GenerationUpdateBuilder {
+	b.update.Metadata = metadata
+	return b
+}
+
+// Build returns the built GenerationUpdateEvent
+func (b *GenerationUpdateBuilder) Build() *GenerationUpdateEvent {
+	return b.update
+}
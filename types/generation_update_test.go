@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerationUpdateEvent_Clone(t *testing.T) {
+	testID := uuid.New()
+	testEndTime := time.Now().UTC()
+	usage := NewUsage().WithTokens(10, 20).Build()
+
+	tests := []struct {
+		name       string
+		input      *GenerationUpdateEvent
+		want       *GenerationUpdateEvent
+		validateFn func(t *testing.T, original, clone *GenerationUpdateEvent)
+	}{
+		{
+			name:  "nil event returns nil",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name:  "empty event",
+			input: &GenerationUpdateEvent{},
+			want:  &GenerationUpdateEvent{},
+		},
+		{
+			name: "event with update fields populated",
+			input: &GenerationUpdateEvent{
+				ID:              &testID,
+				EndTime:         &testEndTime,
+				Output:          map[string]any{"completion": "hello"},
+				Usage:           &usage,
+				ModelParameters: map[string]any{"temperature": 0.2},
+			},
+			want: &GenerationUpdateEvent{
+				ID:              &testID,
+				EndTime:         &testEndTime,
+				Output:          map[string]any{"completion": "hello"},
+				Usage:           &usage,
+				ModelParameters: map[string]any{"temperature": 0.2},
+			},
+			validateFn: func(t *testing.T, original, clone *GenerationUpdateEvent) {
+				original.ModelParameters["temperature"] = modifiedText
+				assert.InDelta(t, 0.2, clone.ModelParameters["temperature"], 0, "ModelParameters should be deep copied")
+
+				assert.NotSame(t, original.ID, clone.ID, "ID pointers should be different")
+				assert.NotSame(t, original.Usage, clone.Usage, "Usage pointers should be different")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.Clone()
+
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			clonedUpdate, ok := got.(*GenerationUpdateEvent)
+			require.True(t, ok, "Clone should return *GenerationUpdateEvent")
+
+			assert.Equal(t, tt.want, clonedUpdate)
+
+			if tt.validateFn != nil {
+				tt.validateFn(t, tt.input, clonedUpdate)
+			}
+		})
+	}
+}
+
+func TestGenerationUpdateEvent_MarshalOnlySetFields(t *testing.T) {
+	id := uuid.MustParse("f8359e80-1ecd-471b-bf2a-49d2009a9179")
+	subject := NewGenerationUpdate(id).WithOutput("done").Build()
+
+	got, err := json.Marshal(subject)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"f8359e80-1ecd-471b-bf2a-49d2009a9179","output":"done"}`, string(got))
+}
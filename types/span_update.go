@@ -0,0 +1,173 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SpanUpdateEvent A partial update of a span that was previously created with SpanEvent.
+// Only the fields that are set are sent to langfuse, so fields which are not set keep the values
+// they were created with. The ID is required and must match the ID of the span being updated, events without it are
+// rejected with INVALID_EVENT_ID.
+// Fields:
+//   - ID the id of the span to update.
+//   - TraceID trace id of the span, optional for updates.
+//   - ParentObservationID the ID of the parent observation, if applicable.
+//   - Name identifier of the span. Useful for sorting/filtering in the UI.
+//   - StartTime the time at which the span started.
+//   - EndTime the time at which the span ended.
+//   - Metadata of the span. It is merged when being updated via the API.
+//   - Level the level of the span. Used for sorting/filtering of traces with elevated error levels and for highlighting in the UI.
+//   - StatusMessage the additional field for context of the event. E.g. the error message of an error event.
+//   - Input the input to the span. Can be any JSON object.
+//   - Output the output to the span. Can be any JSON object.
+//   - Version the version of the span type. Used to understand how changes to the span type affect metrics. Useful in debugging.
+//   - Environment the environment in which the trace was created, e.g. "production", "staging", etc.
+type SpanUpdateEvent struct {
+	ID                  *uuid.UUID     `json:"id" valid:"-"`
+	TraceID             *uuid.UUID     `json:"traceId,omitempty" valid:"-"`
+	ParentObservationID *uuid.UUID     `json:"parentObservationId,omitempty" valid:"-"`
+	Name                string         `json:"name,omitempty" valid:"-"`
+	StartTime           *time.Time     `json:"startTime,omitempty" valid:"-"`
+	EndTime             *time.Time     `json:"endTime,omitempty" valid:"-"`
+	Metadata            map[string]any `json:"metadata,omitempty" valid:"-"`
+	Level               Level          `json:"level,omitempty" valid:"-"`
+	StatusMessage       string         `json:"statusMessage,omitempty" valid:"-"`
+	Input               any            `json:"input,omitempty" valid:"-"`
+	Output              any            `json:"output,omitempty" valid:"-"`
+	Version             string         `json:"version,omitempty" valid:"-"`
+	Environment         string         `json:"environment,omitempty" valid:"-"`
+}
+
+// GetID return an event ID
+func (t *SpanUpdateEvent) GetID() *uuid.UUID {
+	return t.ID
+}
+
+// SetID set event ID
+func (t *SpanUpdateEvent) SetID(id *uuid.UUID) {
+	t.ID = id
+}
+
+// Clone creates a deep copy of the SpanUpdateEvent
+func (t *SpanUpdateEvent) Clone() LangfuseEvent {
+	if t == nil {
+		return nil
+	}
+
+	clone := &SpanUpdateEvent{
+		Name:          t.Name,
+		Level:         t.Level,
+		StatusMessage: t.StatusMessage,
+		Version:       t.Version,
+		Environment:   t.Environment,
+	}
+
+	// Deep copy pointer fields
+	if t.ID != nil {
+		id := *t.ID
+		clone.ID = &id
+	}
+
+	if t.TraceID != nil {
+		traceID := *t.TraceID
+		clone.TraceID = &traceID
+	}
+
+	if t.ParentObservationID != nil {
+		parentID := *t.ParentObservationID
+		clone.ParentObservationID = &parentID
+	}
+
+	if t.StartTime != nil {
+		startTime := *t.StartTime
+		clone.StartTime = &startTime
+	}
+
+	if t.EndTime != nil {
+		endTime := *t.EndTime
+		clone.EndTime = &endTime
+	}
+
+	// Deep copy map
+	if t.Metadata != nil {
+		clone.Metadata = make(map[string]any, len(t.Metadata))
+		for k, v := range t.Metadata {
+			clone.Metadata[k] = deepCopyAny(v)
+		}
+	}
+
+	// Deep copy any fields
+	clone.Input = deepCopyAny(t.Input)
+	clone.Output = deepCopyAny(t.Output)
+
+	return clone
+}
+
+// Error set Level to error and EndTime with status message
+func (t *SpanUpdateEvent) Error(statusMessage string) *SpanUpdateEvent {
+	t.StatusMessage = statusMessage
+	t.Level = Error
+	return t.End()
+}
+
+// End set end time to now
+func (t *SpanUpdateEvent) End() *SpanUpdateEvent {
+	now := time.Now().UTC()
+	t.EndTime = &now
+	return t
+}
+
+// SpanUpdateBuilder provides a fluent interface for building SpanUpdateEvent
+type SpanUpdateBuilder struct {
+	update *SpanUpdateEvent
+}
+
+// NewSpanUpdate creates a new SpanUpdateBuilder for the span with the given ID
+func NewSpanUpdate(id uuid.UUID) *SpanUpdateBuilder {
+	return &SpanUpdateBuilder{
+		update: &SpanUpdateEvent{ID: &id},
+	}
+}
+
+// WithTraceID sets the trace ID
+func (b *SpanUpdateBuilder) WithTraceID(traceID uuid.UUID) *SpanUpdateBuilder {
+	b.update.TraceID = &traceID
+	return b
+}
+
+// WithEndTime sets the end time
+func (b *SpanUpdateBuilder) WithEndTime(endTime time.Time) *SpanUpdateBuilder {
+	b.update.EndTime = &endTime
+	return b
+}
+
+// WithOutput sets the output
+func (b *SpanUpdateBuilder) WithOutput(output any) *SpanUpdateBuilder {
+	b.update.Output = output
+	return b
+}
+
+// WithLevel sets the level
+func (b *SpanUpdateBuilder) WithLevel(level Level) *SpanUpdateBuilder {
+	b.update.Level = level
+	return b
+}
+
+// WithStatusMessage sets the status message
+func (b *SpanUpdateBuilder) WithStatusMessage(statusMessage string) *SpanUpdateBuilder {
+	b.update.StatusMessage = statusMessage
+	return b
+}
+
+// WithMetadata sets the metadata
+func (b *SpanUpdateBuilder) WithMetadata(metadata map[string]any) *SpanUpdateBuilder {
+	b.update.Metadata = metadata
+	return b
+}
+
+// Build returns the built SpanUpdateEvent
+func (b *SpanUpdateBuilder) Build() *SpanUpdateEvent {
+	return b.update
+}
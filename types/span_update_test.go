@@ -0,0 +1,95 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpanUpdateEvent_Clone(t *testing.T) {
+	testID := uuid.New()
+	testTraceID := uuid.New()
+	testEndTime := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		input      *SpanUpdateEvent
+		want       *SpanUpdateEvent
+		validateFn func(t *testing.T, original, clone *SpanUpdateEvent)
+	}{
+		{
+			name:  "nil event returns nil",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name:  "empty event",
+			input: &SpanUpdateEvent{},
+			want:  &SpanUpdateEvent{},
+		},
+		{
+			name: "event with update fields populated",
+			input: &SpanUpdateEvent{
+				ID:            &testID,
+				TraceID:       &testTraceID,
+				EndTime:       &testEndTime,
+				Metadata:      map[string]any{"step": "retrieval"},
+				Level:         Warning,
+				StatusMessage: "slow response",
+				Output:        map[string]any{"documents": 3},
+			},
+			want: &SpanUpdateEvent{
+				ID:            &testID,
+				TraceID:       &testTraceID,
+				EndTime:       &testEndTime,
+				Metadata:      map[string]any{"step": "retrieval"},
+				Level:         Warning,
+				StatusMessage: "slow response",
+				Output:        map[string]any{"documents": 3},
+			},
+			validateFn: func(t *testing.T, original, clone *SpanUpdateEvent) {
+				original.Metadata["step"] = modifiedText
+				assert.Equal(t, "retrieval", clone.Metadata["step"], "Metadata should be deep copied")
+
+				assert.NotSame(t, original.ID, clone.ID, "ID pointers should be different")
+				assert.NotSame(t, original.TraceID, clone.TraceID, "TraceID pointers should be different")
+				assert.NotSame(t, original.EndTime, clone.EndTime, "EndTime pointers should be different")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.Clone()
+
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			clonedUpdate, ok := got.(*SpanUpdateEvent)
+			require.True(t, ok, "Clone should return *SpanUpdateEvent")
+
+			assert.Equal(t, tt.want, clonedUpdate)
+
+			if tt.validateFn != nil {
+				tt.validateFn(t, tt.input, clonedUpdate)
+			}
+		})
+	}
+}
+
+func TestSpanUpdateEvent_MarshalOnlySetFields(t *testing.T) {
+	id := uuid.MustParse("f8359e80-1ecd-471b-bf2a-49d2009a9179")
+	subject := NewSpanUpdate(id).WithOutput("done").WithLevel(Error).Build()
+
+	got, err := json.Marshal(subject)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"f8359e80-1ecd-471b-bf2a-49d2009a9179","output":"done","level":"ERROR"}`, string(got))
+}